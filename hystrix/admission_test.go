@@ -0,0 +1,253 @@
+package hystrix_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/afex/hystrix-go/hystrix/clock"
+	"github.com/afex/hystrix-go/hystrix/hystrixtest"
+	"github.com/afex/hystrix-go/hystrix/metric_collector"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func succeed(ctx context.Context) error {
+	return nil
+}
+
+// fallbackWith returns a fallback which succeeds, recording the error it ran with.
+func fallbackWith(errs chan<- error) func(context.Context, error) error {
+	return func(ctx context.Context, err error) error {
+		errs <- err
+		return nil
+	}
+}
+
+// blockOne starts an execution of the named command which holds its ticket
+// until the returned function is called.
+func blockOne(ctx context.Context, name string) (unblock func()) {
+	block := make(chan struct{})
+	started := make(chan struct{})
+	hystrix.GoC(ctx, name, func(ctx context.Context) error {
+		close(started)
+		<-block
+		return nil
+	}, nil)
+	<-started
+	return func() { close(block) }
+}
+
+func TestRateLimitedAdmission(t *testing.T) {
+	Convey("with a command limited to 1 execution per second in bursts of 2", t, func() {
+		hystrixtest.Isolate(t)
+		errs := make(chan error, 1)
+
+		Convey("executions beyond the burst are rate limited without counting as errors", func() {
+			hystrix.ConfigureCommand("limited", hystrix.CommandConfig{RequestsPerSecond: 1, Burst: 2})
+			recorder := hystrixtest.RecordMetrics(t, "limited")
+
+			So(hystrix.DoC(context.Background(), "limited", succeed, nil), ShouldBeNil)
+			So(hystrix.DoC(context.Background(), "limited", succeed, nil), ShouldBeNil)
+			So(hystrix.DoC(context.Background(), "limited", succeed, fallbackWith(errs)), ShouldBeNil)
+			So(<-errs, ShouldResemble, hystrix.ErrRateLimited)
+
+			recorder.Wait(t, 3)
+			recorder.AssertCounts(t, metricCollector.MetricResult{
+				Attempts:          2,
+				Successes:         2,
+				RateLimited:       1,
+				FallbackSuccesses: 1,
+			})
+		})
+
+		Convey("an execution rejected by the pool does not use up a token", func() {
+			hystrix.ConfigureCommand("limited", hystrix.CommandConfig{RequestsPerSecond: 1, Burst: 2, MaxConcurrentRequests: 1})
+			recorder := hystrixtest.RecordMetrics(t, "limited")
+
+			unblock := blockOne(context.Background(), "limited")
+			So(hystrix.DoC(context.Background(), "limited", succeed, fallbackWith(errs)), ShouldBeNil)
+			So(<-errs, ShouldResemble, hystrix.ErrMaxConcurrency)
+			unblock()
+			recorder.Wait(t, 2)
+
+			So(hystrix.DoC(context.Background(), "limited", succeed, nil), ShouldBeNil)
+		})
+	})
+}
+
+func TestInsufficientDeadlineAdmission(t *testing.T) {
+	Convey("with a command admitting by the 50th percentile of run durations", t, func() {
+		hystrixtest.Isolate(t)
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		hystrix.ConfigureCommand("deadline", hystrix.CommandConfig{DeadlineAdmissionPercentile: 50})
+		recorder := hystrixtest.RecordMetrics(t, "deadline")
+
+		So(hystrix.DoC(context.Background(), "deadline", func(ctx context.Context) error {
+			fake.Advance(50 * time.Millisecond)
+			return nil
+		}, nil), ShouldBeNil)
+		recorder.Wait(t, 1)
+		recorder.Reset()
+
+		Convey("a command whose deadline is too close is rejected without counting as an error", func() {
+			ctx, cancel := clock.WithTimeout(context.Background(), fake, 5*time.Millisecond)
+			defer cancel()
+			So(hystrix.DoC(ctx, "deadline", succeed, nil), ShouldResemble, hystrix.ErrInsufficientDeadline)
+
+			recorder.Wait(t, 1)
+			recorder.AssertCounts(t, metricCollector.MetricResult{InsufficientDeadline: 1})
+		})
+
+		Convey("executions which never ran leave the run durations alone", func() {
+			hystrixtest.RejectNext(t, "deadline", 10)
+			for i := 0; i < 10; i++ {
+				So(hystrix.DoC(context.Background(), "deadline", succeed, nil), ShouldResemble, hystrix.ErrMaxConcurrency)
+			}
+			recorder.Wait(t, 10)
+
+			for i := 0; i < 20; i++ {
+				ctx, cancel := clock.WithTimeout(context.Background(), fake, 10*time.Millisecond)
+				So(hystrix.DoC(ctx, "deadline", succeed, nil), ShouldResemble, hystrix.ErrInsufficientDeadline)
+				cancel()
+				recorder.Wait(t, 11+i)
+			}
+		})
+
+		Convey("a command with enough time runs", func() {
+			ctx, cancel := clock.WithTimeout(context.Background(), fake, time.Second)
+			defer cancel()
+			So(hystrix.DoC(ctx, "deadline", succeed, nil), ShouldBeNil)
+		})
+
+		Convey("a command without a deadline runs", func() {
+			So(hystrix.DoC(context.Background(), "deadline", succeed, nil), ShouldBeNil)
+		})
+	})
+}
+
+func TestSlowStartAdmission(t *testing.T) {
+	Convey("with a command which has just closed and admits 5% of traffic", t, func() {
+		hystrixtest.Isolate(t)
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+
+		hystrix.ConfigureCommand("starting", hystrix.CommandConfig{SlowStartWindow: 1000})
+		hystrixtest.StartSlowStart(t, "starting")
+		recorder := hystrixtest.RecordMetrics(t, "starting")
+
+		Convey("executions turned away run their fallback without counting as errors", func() {
+			errs := make(chan error, 100)
+			for i := 0; i < 100; i++ {
+				So(hystrix.DoC(context.Background(), "starting", succeed, fallbackWith(errs)), ShouldBeNil)
+			}
+			close(errs)
+
+			rejected := 0
+			for err := range errs {
+				So(err, ShouldResemble, hystrix.ErrSlowStart)
+				rejected++
+			}
+			So(rejected, ShouldBeGreaterThan, 50)
+
+			recorder.Wait(t, 100)
+			recorder.AssertCounts(t, metricCollector.MetricResult{
+				Attempts:          float64(100 - rejected),
+				Successes:         float64(100 - rejected),
+				SlowStartRejects:  float64(rejected),
+				FallbackSuccesses: float64(rejected),
+			})
+			cb, _, _ := hystrix.GetCircuit("starting")
+			So(cb.IsOpen(), ShouldBeFalse)
+		})
+	})
+}
+
+func TestBulkheadKeyAdmission(t *testing.T) {
+	Convey("with a command limited to 1 execution per key", t, func() {
+		hystrixtest.Isolate(t)
+		hystrix.ConfigureCommand("keyed", hystrix.CommandConfig{MaxConcurrentRequestsPerKey: 1})
+		recorder := hystrixtest.RecordMetrics(t, "keyed")
+
+		noisy := hystrix.WithBulkheadKey(context.Background(), "noisy")
+		quiet := hystrix.WithBulkheadKey(context.Background(), "quiet")
+		unblock := blockOne(noisy, "keyed")
+		defer unblock()
+
+		Convey("another execution for the same key is rejected without counting as an error", func() {
+			So(hystrix.DoC(noisy, "keyed", succeed, nil), ShouldResemble, hystrix.ErrKeyMaxConcurrency)
+
+			results := recorder.Wait(t, 1)
+			So(results[0].BulkheadKey, ShouldEqual, "noisy")
+			recorder.AssertCounts(t, metricCollector.MetricResult{Rejects: 1})
+		})
+
+		Convey("executions for another key run, releasing the key as they finish", func() {
+			So(hystrix.DoC(quiet, "keyed", succeed, nil), ShouldBeNil)
+			recorder.Wait(t, 1)
+			So(hystrix.DoC(quiet, "keyed", succeed, nil), ShouldBeNil)
+		})
+	})
+}
+
+func TestLoadSheddingAdmission(t *testing.T) {
+	Convey("with a pool of 4 reserving a quarter of it for each criticality above sheddable", t, func() {
+		hystrixtest.Isolate(t)
+		hystrix.ConfigureCommand("shedding", hystrix.CommandConfig{MaxConcurrentRequests: 4, PriorityReservePercent: 25})
+		recorder := hystrixtest.RecordMetrics(t, "shedding")
+
+		Convey("concurrent sheddable executions take no more than their half", func() {
+			sheddable := hystrix.WithCriticality(context.Background(), hystrix.CriticalitySheddable)
+			block := make(chan struct{})
+			outcomes := make(chan error, 10)
+			for i := 0; i < 10; i++ {
+				hystrix.GoC(sheddable, "shedding", func(ctx context.Context) error {
+					outcomes <- nil
+					<-block
+					return nil
+				}, fallbackWith(outcomes))
+			}
+
+			ran := 0
+			for i := 0; i < 10; i++ {
+				if err := <-outcomes; err == nil {
+					ran++
+				} else {
+					So(err, ShouldResemble, hystrix.ErrLoadShed)
+				}
+			}
+			close(block)
+			So(ran, ShouldEqual, 2)
+
+			Convey("and the rest are shed without counting as errors", func() {
+				recorder.Wait(t, 10)
+				recorder.AssertCounts(t, metricCollector.MetricResult{
+					Attempts:          2,
+					Successes:         2,
+					Shed:              8,
+					FallbackSuccesses: 8,
+				})
+			})
+		})
+
+		Convey("once default executions have taken their share", func() {
+			for i := 0; i < 3; i++ {
+				defer blockOne(context.Background(), "shedding")()
+			}
+
+			Convey("another default execution is shed", func() {
+				So(hystrix.DoC(context.Background(), "shedding", succeed, nil), ShouldResemble, hystrix.ErrLoadShed)
+				results := recorder.Wait(t, 1)
+				So(results[0].Criticality, ShouldEqual, string(hystrix.CriticalityDefault))
+			})
+
+			Convey("a critical execution takes the ticket kept for it", func() {
+				critical := hystrix.WithCriticality(context.Background(), hystrix.CriticalityCritical)
+				So(hystrix.DoC(critical, "shedding", succeed, nil), ShouldBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,94 @@
+package hystrix
+
+import (
+	"context"
+	"sync"
+)
+
+type bulkheadKeyContextKey struct{}
+
+// WithBulkheadKey returns a context which limits the commands it runs to
+// MaxConcurrentRequestsPerKey concurrent executions for the given key, in
+// addition to the command's MaxConcurrentRequests. Use it to keep one caller,
+// such as a tenant, from starving everyone else of the command's tickets.
+func WithBulkheadKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, bulkheadKeyContextKey{}, key)
+}
+
+func bulkheadKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(bulkheadKeyContextKey{}).(string)
+	return key, ok
+}
+
+// keyedBulkhead tracks concurrent executions per bulkhead key. Keys are only
+// kept while they have executions in flight, so memory is bounded by the
+// command's concurrency rather than the number of distinct keys.
+type keyedBulkhead struct {
+	Name string
+	Max  int
+
+	mutex  *sync.Mutex
+	active map[string]int
+}
+
+// newKeyedBulkhead returns the bulkhead for the named command, or nil if the
+// command has no MaxConcurrentRequestsPerKey configured.
+func newKeyedBulkhead(name string) *keyedBulkhead {
+	max := getSettings(name).MaxConcurrentRequestsPerKey
+	if max <= 0 {
+		return nil
+	}
+
+	b := &keyedBulkhead{}
+	b.Name = name
+	b.Max = max
+	b.mutex = &sync.Mutex{}
+	b.active = make(map[string]int)
+
+	return b
+}
+
+// Acquire reserves a slot for key, returning false if the key is at capacity.
+// A nil keyedBulkhead allows everything.
+func (b *keyedBulkhead) Acquire(key string) bool {
+	if b == nil {
+		return true
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.active[key] >= b.Max {
+		return false
+	}
+	b.active[key]++
+
+	return true
+}
+
+// Release frees a slot reserved by Acquire.
+func (b *keyedBulkhead) Release(key string) {
+	if b == nil {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.active[key]--
+	if b.active[key] <= 0 {
+		delete(b.active, key)
+	}
+}
+
+// ActiveCount returns the number of executions in flight for key.
+func (b *keyedBulkhead) ActiveCount(key string) int {
+	if b == nil {
+		return 0
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.active[key]
+}
@@ -0,0 +1,54 @@
+package hystrix
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestKeyedBulkhead(t *testing.T) {
+	Convey("given a bulkhead allowing 1 execution per key", t, func() {
+		ConfigureCommand("keyed", CommandConfig{MaxConcurrentRequestsPerKey: 1})
+		b := newKeyedBulkhead("keyed")
+
+		Convey("each key may acquire one slot", func() {
+			So(b.Acquire("a"), ShouldBeTrue)
+			So(b.Acquire("b"), ShouldBeTrue)
+			So(b.Acquire("a"), ShouldBeFalse)
+
+			Convey("and releasing frees the slot", func() {
+				b.Release("a")
+				So(b.Acquire("a"), ShouldBeTrue)
+			})
+		})
+
+		Convey("released keys are forgotten", func() {
+			b.Acquire("a")
+			b.Release("a")
+			So(len(b.active), ShouldEqual, 0)
+		})
+	})
+
+	Convey("given a command without a per key limit", t, func() {
+		ConfigureCommand("unkeyed", CommandConfig{})
+		b := newKeyedBulkhead("unkeyed")
+
+		Convey("no bulkhead is created and everything is allowed", func() {
+			So(b, ShouldBeNil)
+			So(b.Acquire("a"), ShouldBeTrue)
+			b.Release("a")
+		})
+	})
+}
+
+func TestBulkheadKeyFromContext(t *testing.T) {
+	Convey("a key set with WithBulkheadKey can be read back", t, func() {
+		key, ok := bulkheadKeyFromContext(WithBulkheadKey(context.Background(), "tenant"))
+		So(ok, ShouldBeTrue)
+		So(key, ShouldEqual, "tenant")
+
+		_, ok = bulkheadKeyFromContext(context.Background())
+		So(ok, ShouldBeFalse)
+	})
+}
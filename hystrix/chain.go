@@ -0,0 +1,70 @@
+package hystrix
+
+import (
+	"bytes"
+	"context"
+)
+
+// A ChainLink is one step of a fallback chain. Each link runs as a command of
+// its own, with the circuit, pool and timeout configured for its Name.
+type ChainLink struct {
+	Name string
+	Run  runFuncC
+}
+
+// A ChainAttempt records why a link of a fallback chain failed.
+type ChainAttempt struct {
+	Name string
+	Err  error
+}
+
+// A ChainError is returned when no link of a fallback chain succeeds. It lists
+// every link which was tried, in order, with the error it failed with.
+type ChainError struct {
+	Attempts []ChainAttempt
+}
+
+func (e ChainError) Error() string {
+	var b bytes.Buffer
+	b.WriteString("hystrix: fallback chain failed")
+	for i, a := range e.Attempts {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString(", ")
+		}
+		b.WriteString(a.Name)
+		b.WriteString(" (")
+		b.WriteString(a.Err.Error())
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+// DoChainC runs the links in order until one succeeds, such as a primary
+// service, then a secondary region, then a stale cache. Unlike a fallback
+// function, every link is protected by its own circuit, so an unhealthy
+// secondary is short-circuited just like an unhealthy primary.
+//
+// Links are not tried once ctx is done. When that stops the chain part way
+// through, the link it stopped at is listed with ctx's error.
+func DoChainC(ctx context.Context, links []ChainLink) error {
+	var chainErr ChainError
+	for _, link := range links {
+		if err := ctx.Err(); err != nil {
+			if len(chainErr.Attempts) == 0 {
+				return err
+			}
+			chainErr.Attempts = append(chainErr.Attempts, ChainAttempt{Name: link.Name, Err: err})
+			break
+		}
+
+		err := DoC(ctx, link.Name, link.Run, nil)
+		if err == nil {
+			return nil
+		}
+		chainErr.Attempts = append(chainErr.Attempts, ChainAttempt{Name: link.Name, Err: err})
+	}
+
+	return chainErr
+}
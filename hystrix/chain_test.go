@@ -0,0 +1,91 @@
+package hystrix
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDoChainC(t *testing.T) {
+	Convey("with a chain whose primary is open and whose secondary fails", t, func() {
+		defer Flush()
+
+		primary, _, _ := GetCircuit("primary")
+		primary.setOpen()
+
+		var ran []string
+		links := []ChainLink{
+			{Name: "primary", Run: func(ctx context.Context) error {
+				ran = append(ran, "primary")
+				return nil
+			}},
+			{Name: "secondary", Run: func(ctx context.Context) error {
+				ran = append(ran, "secondary")
+				return fmt.Errorf("unavailable")
+			}},
+		}
+
+		Convey("and a static default which succeeds", func() {
+			links = append(links, ChainLink{Name: "default", Run: func(ctx context.Context) error {
+				ran = append(ran, "default")
+				return nil
+			}})
+
+			Convey("the chain succeeds after skipping the open circuit", func() {
+				So(DoChainC(context.Background(), links), ShouldBeNil)
+				So(ran, ShouldResemble, []string{"secondary", "default"})
+			})
+		})
+
+		Convey("the chain fails naming every link tried", func() {
+			err := DoChainC(context.Background(), links)
+			So(err.Error(), ShouldEqual, "hystrix: fallback chain failed: primary (hystrix: circuit open), secondary (unavailable)")
+
+			chainErr, ok := err.(ChainError)
+			So(ok, ShouldBeTrue)
+			So(chainErr.Attempts[0].Err, ShouldResemble, ErrCircuitOpen)
+		})
+	})
+
+	Convey("with a context which is cancelled by the first link", t, func() {
+		defer Flush()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ran := false
+		links := []ChainLink{
+			{Name: "primary", Run: func(ctx context.Context) error {
+				cancel()
+				return ctx.Err()
+			}},
+			{Name: "secondary", Run: func(ctx context.Context) error {
+				ran = true
+				return nil
+			}},
+		}
+
+		Convey("the chain stops, naming the link it stopped at", func() {
+			err := DoChainC(ctx, links)
+			So(ran, ShouldBeFalse)
+			So(err, ShouldResemble, ChainError{Attempts: []ChainAttempt{
+				{Name: "primary", Err: context.Canceled},
+				{Name: "secondary", Err: context.Canceled},
+			}})
+		})
+	})
+
+	Convey("with a context which is already done", t, func() {
+		defer Flush()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		Convey("no link is tried", func() {
+			err := DoChainC(ctx, []ChainLink{{Name: "primary", Run: func(ctx context.Context) error {
+				return nil
+			}}})
+			So(err, ShouldEqual, context.Canceled)
+		})
+	})
+}
@@ -1,10 +1,13 @@
 package hystrix
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
 )
 
 // CircuitBreaker is created for each ExecutorPool to track whether requests
@@ -15,11 +18,37 @@ type CircuitBreaker struct {
 	forceOpen              bool
 	mutex                  *sync.RWMutex
 	openedOrLastTestedTime int64
+	closedTime             int64
+	lastUsedTime           int64
+	// holds counts the commands in flight on the circuit, which keep it from
+	// being evicted until they have reported their metrics.
+	holds     int64
+	closeOnce *sync.Once
+	// injectedErrors are taken by executions in place of running, once
+	// hystrixtest has injected them. pendingInjections counts them, so that
+	// executions only take the mutex to look for one when there is one.
+	injectedErrors    []error
+	pendingInjections int64
+	clock             clock.Clock
 
 	executorPool *executorPool
+	rateLimiter  *rateLimiter
+	bulkhead     *keyedBulkhead
 	metrics      *metricExchange
 }
 
+// CircuitState describes whether a circuit is letting executions through.
+type CircuitState string
+
+const (
+	// CircuitClosed circuits allow every execution.
+	CircuitClosed CircuitState = "CLOSED"
+	// CircuitOpen circuits reject executions until their sleep window has passed.
+	CircuitOpen CircuitState = "OPEN"
+	// CircuitHalfOpen circuits are open, but are allowing a single execution to test whether to close.
+	CircuitHalfOpen CircuitState = "HALF_OPEN"
+)
+
 var (
 	circuitBreakersMutex *sync.RWMutex
 	circuitBreakers      map[string]*CircuitBreaker
@@ -32,35 +61,89 @@ func init() {
 
 // GetCircuit returns the circuit for the given command and whether this call created it.
 func GetCircuit(name string) (*CircuitBreaker, bool, error) {
+	return getCircuit(name, false)
+}
+
+// acquireCircuit returns the circuit for a command to execute on. The circuit is
+// held until the command calls release, so that it is not evicted, and its
+// metrics closed, while the command is in flight.
+func acquireCircuit(name string) (*CircuitBreaker, error) {
+	cb, _, err := getCircuit(name, true)
+	return cb, err
+}
+
+// getCircuit returns the named circuit, creating it if need be, and holds it
+// if hold is set. Eviction checks the holds while holding circuitBreakersMutex
+// exclusively, so a circuit held here can no longer be evicted.
+func getCircuit(name string, hold bool) (*CircuitBreaker, bool, error) {
 	circuitBreakersMutex.RLock()
-	_, ok := circuitBreakers[name]
-	if !ok {
-		circuitBreakersMutex.RUnlock()
-		circuitBreakersMutex.Lock()
-		defer circuitBreakersMutex.Unlock()
-		// because we released the rlock before we obtained the exclusive lock,
-		// we need to double check that some other thread didn't beat us to
-		// creation.
-		if cb, ok := circuitBreakers[name]; ok {
-			return cb, false, nil
+	cb, ok := circuitBreakers[name]
+	if ok && hold {
+		cb.hold()
+	}
+	circuitBreakersMutex.RUnlock()
+	if ok {
+		cb.touch()
+		return cb, false, nil
+	}
+
+	circuitBreakersMutex.Lock()
+	// because we released the rlock before we obtained the exclusive lock,
+	// we need to double check that some other thread didn't beat us to
+	// creation.
+	if cb, ok := circuitBreakers[name]; ok {
+		if hold {
+			cb.hold()
 		}
-		circuitBreakers[name] = newCircuitBreaker(name)
-	} else {
-		defer circuitBreakersMutex.RUnlock()
+		circuitBreakersMutex.Unlock()
+		cb.touch()
+		return cb, false, nil
+	}
+	evicted := evictForCapacityLocked()
+	cb = newCircuitBreaker(name)
+	if hold {
+		cb.hold()
+	}
+	circuitBreakers[name] = cb
+	circuitBreakersMutex.Unlock()
+
+	// closing waits for the monitors to drain, so do it outside of the lock.
+	for _, e := range evicted {
+		e.Close()
 	}
 
-	return circuitBreakers[name], !ok, nil
+	return cb, true, nil
+}
+
+// RemoveCircuit closes the named circuit and purges it from memory, returning
+// whether it existed. A later execution of the command creates a fresh circuit.
+func RemoveCircuit(name string) bool {
+	circuitBreakersMutex.Lock()
+	cb, ok := circuitBreakers[name]
+	delete(circuitBreakers, name)
+	circuitBreakersMutex.Unlock()
+
+	if ok {
+		cb.Close()
+	}
+
+	return ok
 }
 
 // Flush purges all circuit and metric information from memory.
 func Flush() {
 	circuitBreakersMutex.Lock()
-	defer circuitBreakersMutex.Unlock()
-
+	flushed := make([]*CircuitBreaker, 0, len(circuitBreakers))
 	for name, cb := range circuitBreakers {
+		flushed = append(flushed, cb)
+		delete(circuitBreakers, name)
+	}
+	circuitBreakersMutex.Unlock()
+
+	for _, cb := range flushed {
 		cb.metrics.Reset()
 		cb.executorPool.Metrics.Reset()
-		delete(circuitBreakers, name)
+		cb.Close()
 	}
 }
 
@@ -68,13 +151,57 @@ func Flush() {
 func newCircuitBreaker(name string) *CircuitBreaker {
 	c := &CircuitBreaker{}
 	c.Name = name
+	// the metrics and rate limiter take the default clock as they are created
+	// too, so take it first for them all to share it.
+	c.clock = clock.Default()
 	c.metrics = newMetricExchange(name)
 	c.executorPool = newExecutorPool(name)
+	c.rateLimiter = newRateLimiter(name)
+	c.bulkhead = newKeyedBulkhead(name)
 	c.mutex = &sync.RWMutex{}
+	c.closeOnce = &sync.Once{}
+	c.touch()
 
 	return c
 }
 
+// Close stops the goroutines monitoring this circuit's metrics and closes its
+// metric collectors. Commands still running on a closed circuit complete
+// normally, but their metrics are discarded. Close does not remove the circuit
+// from the registry; use RemoveCircuit for that.
+func (circuit *CircuitBreaker) Close() {
+	circuit.closeOnce.Do(func() {
+		circuit.metrics.Close()
+		circuit.executorPool.Metrics.Close()
+	})
+}
+
+// touch records that the circuit has just been used, for idle eviction.
+func (circuit *CircuitBreaker) touch() {
+	atomic.StoreInt64(&circuit.lastUsedTime, circuit.clock.Now().UnixNano())
+}
+
+func (circuit *CircuitBreaker) lastUsed() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&circuit.lastUsedTime))
+}
+
+// hold keeps the circuit from being evicted until release is called. The
+// caller must hold circuitBreakersMutex, for reading at least.
+func (circuit *CircuitBreaker) hold() {
+	atomic.AddInt64(&circuit.holds, 1)
+}
+
+// release lets go of a hold taken by acquireCircuit.
+func (circuit *CircuitBreaker) release() {
+	atomic.AddInt64(&circuit.holds, -1)
+}
+
+// inUse reports whether any command holds the circuit. The caller must hold
+// circuitBreakersMutex exclusively for the answer to stay true.
+func (circuit *CircuitBreaker) inUse() bool {
+	return atomic.LoadInt64(&circuit.holds) > 0
+}
+
 // toggleForceOpen allows manually causing the fallback logic for all instances
 // of a given command.
 func (circuit *CircuitBreaker) toggleForceOpen(toggle bool) error {
@@ -98,11 +225,12 @@ func (circuit *CircuitBreaker) IsOpen() bool {
 		return true
 	}
 
-	if uint64(circuit.metrics.Requests().Sum(time.Now())) < getSettings(circuit.Name).RequestVolumeThreshold {
+	now := circuit.clock.Now()
+	if uint64(circuit.metrics.Requests().Sum(now)) < getSettings(circuit.Name).RequestVolumeThreshold {
 		return false
 	}
 
-	if !circuit.metrics.IsHealthy(time.Now()) {
+	if !circuit.metrics.IsHealthy(now) {
 		// too many failures, open the circuit
 		circuit.setOpen()
 		return true
@@ -115,24 +243,72 @@ func (circuit *CircuitBreaker) IsOpen() bool {
 // When the circuit is open, this call will occasionally return true to measure whether the external service
 // has recovered.
 func (circuit *CircuitBreaker) AllowRequest() bool {
-	return !circuit.IsOpen() || circuit.allowSingleTest()
+	state, _ := circuit.admissionState()
+	return state != CircuitOpen
+}
+
+// admissionState decides whether an execution may proceed as AllowRequest does,
+// returning the state the circuit was in for it. When the execution is let
+// through to test a half open circuit, the test is returned too, so that it can
+// be abandoned should the execution be rejected before it starts.
+func (circuit *CircuitBreaker) admissionState() (CircuitState, singleTest) {
+	if !circuit.IsOpen() {
+		return CircuitClosed, singleTest{}
+	}
+	if test, ok := circuit.allowSingleTest(); ok {
+		return CircuitHalfOpen, test
+	}
+	return CircuitOpen, singleTest{}
+}
+
+// hasTimeFor reports whether the deadline of ctx leaves enough time for the
+// command to complete, judged by the configured percentile of recent run durations.
+func (circuit *CircuitBreaker) hasTimeFor(ctx context.Context) bool {
+	percentile := getSettings(circuit.Name).DeadlineAdmissionPercentile
+	if percentile <= 0 {
+		return true
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return true
+	}
+
+	now := circuit.clock.Now()
+	expected := circuit.metrics.DefaultCollector().RunDuration().Snapshot(now).Percentile(float64(percentile))
+	return deadline.Sub(now) >= expected
+}
+
+// singleTest is the execution an open circuit let through to test whether to
+// close, identified by the times it replaced openedOrLastTestedTime with.
+type singleTest struct {
+	previous int64
+	tested   int64
 }
 
-func (circuit *CircuitBreaker) allowSingleTest() bool {
+func (circuit *CircuitBreaker) allowSingleTest() (singleTest, bool) {
 	circuit.mutex.RLock()
 	defer circuit.mutex.RUnlock()
 
-	now := time.Now().UnixNano()
+	now := circuit.clock.Now().UnixNano()
 	openedOrLastTestedTime := atomic.LoadInt64(&circuit.openedOrLastTestedTime)
 	if circuit.open && now > openedOrLastTestedTime+getSettings(circuit.Name).SleepWindow.Nanoseconds() {
 		swapped := atomic.CompareAndSwapInt64(&circuit.openedOrLastTestedTime, openedOrLastTestedTime, now)
 		if swapped {
-			log.Printf("hystrix-go: allowing single test to possibly close circuit %v", circuit.Name)
+			log.Log(LogLevelInfo, "allowing single test to possibly close circuit",
+				LogField{"circuit", circuit.Name}, LogField{"state", CircuitHalfOpen})
 		}
-		return swapped
+		return singleTest{previous: openedOrLastTestedTime, tested: now}, swapped
 	}
 
-	return false
+	return singleTest{}, false
+}
+
+// abandonSingleTest lets the next execution test the circuit in place of one
+// which was rejected before it could start, rather than leaving the circuit
+// open for another sleep window.
+func (circuit *CircuitBreaker) abandonSingleTest(test singleTest) {
+	atomic.CompareAndSwapInt64(&circuit.openedOrLastTestedTime, test.tested, test.previous)
 }
 
 func (circuit *CircuitBreaker) setOpen() {
@@ -143,9 +319,11 @@ func (circuit *CircuitBreaker) setOpen() {
 		return
 	}
 
-	log.Printf("hystrix-go: opening circuit %v", circuit.Name)
+	log.Log(LogLevelWarn, "opening circuit",
+		LogField{"circuit", circuit.Name}, LogField{"state", CircuitOpen},
+		LogField{"error_percent", circuit.metrics.ErrorPercent(circuit.clock.Now())})
 
-	circuit.openedOrLastTestedTime = time.Now().UnixNano()
+	circuit.openedOrLastTestedTime = circuit.clock.Now().UnixNano()
 	circuit.open = true
 }
 
@@ -157,38 +335,46 @@ func (circuit *CircuitBreaker) setClose() {
 		return
 	}
 
-	log.Printf("hystrix-go: closing circuit %v", circuit.Name)
+	log.Log(LogLevelInfo, "closing circuit", LogField{"circuit", circuit.Name}, LogField{"state", CircuitClosed})
 
 	circuit.open = false
+	circuit.closedTime = circuit.clock.Now().UnixNano()
 	circuit.metrics.Reset()
 }
 
 // ReportEvent records command metrics for tracking recent error rates and exposing data to the dashboard.
+// A zero runDuration reports an execution which never ran.
 func (circuit *CircuitBreaker) ReportEvent(eventTypes []string, start time.Time, runDuration time.Duration) error {
-	if len(eventTypes) == 0 {
+	execution := &commandExecution{
+		Types:       eventTypes,
+		Start:       start,
+		RunDuration: runDuration,
+	}
+	if runDuration > 0 {
+		execution.RunStart = start
+	}
+	return circuit.report(execution)
+}
+
+func (circuit *CircuitBreaker) report(execution *commandExecution) error {
+	if len(execution.Types) == 0 {
 		return fmt.Errorf("no event types sent for metrics")
 	}
 
 	circuit.mutex.RLock()
 	o := circuit.open
 	circuit.mutex.RUnlock()
-	if eventTypes[0] == "success" && o {
+	// Only the execution let through to test the circuit may close it, not those
+	// shadow mode let through in its place.
+	if execution.Types[0] == "success" && o && !execution.has(shadowEventPrefix+"short-circuit") {
 		circuit.setClose()
 	}
 
-	var concurrencyInUse float64
 	if circuit.executorPool.Max > 0 {
-		concurrencyInUse = float64(circuit.executorPool.ActiveCount()) / float64(circuit.executorPool.Max)
+		execution.ConcurrencyInUse = float64(circuit.executorPool.ActiveCount()) / float64(circuit.executorPool.Max)
 	}
 
-	select {
-	case circuit.metrics.Updates <- &commandExecution{
-		Types:            eventTypes,
-		Start:            start,
-		RunDuration:      runDuration,
-		ConcurrencyInUse: concurrencyInUse,
-	}:
-	default:
+	if !circuit.metrics.send(execution) {
 		return CircuitError{Message: fmt.Sprintf("metrics channel (%v) is at capacity", circuit.Name)}
 	}
 
@@ -1,6 +1,7 @@
 package hystrix
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -148,3 +149,77 @@ func TestReportEventMultiThreaded(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestRemoveCircuit(t *testing.T) {
+	Convey("when a circuit exists", t, func() {
+		defer Flush()
+
+		cb, _, err := GetCircuit("foo")
+		So(err, ShouldBeNil)
+
+		Convey("removing it returns true", func() {
+			So(RemoveCircuit("foo"), ShouldBeTrue)
+
+			Convey("and stops its monitors", func() {
+				_, metricsOpen := <-cb.metrics.done
+				_, poolOpen := <-cb.executorPool.Metrics.done
+				So(metricsOpen, ShouldBeFalse)
+				So(poolOpen, ShouldBeFalse)
+			})
+
+			Convey("and the next GetCircuit creates a new circuit", func() {
+				next, created, err := GetCircuit("foo")
+				So(err, ShouldBeNil)
+				So(created, ShouldBeTrue)
+				So(next, ShouldNotEqual, cb)
+			})
+		})
+
+		Convey("removing an unknown circuit returns false", func() {
+			So(RemoveCircuit("bar"), ShouldBeFalse)
+		})
+	})
+}
+
+func TestClosedCircuitDiscardsMetrics(t *testing.T) {
+	Convey("when a circuit is closed while a command holds a ticket", t, func() {
+		defer Flush()
+
+		cb, _, err := GetCircuit("foo")
+		So(err, ShouldBeNil)
+		ticket := <-cb.executorPool.Tickets
+		cb.Close()
+
+		Convey("returning the ticket and reporting do not panic", func() {
+			cb.executorPool.Return(ticket)
+			So(cb.ReportEvent([]string{"success"}, time.Now(), 0), ShouldBeNil)
+			So(cb.executorPool.ActiveCount(), ShouldEqual, 0)
+		})
+
+		Convey("closing again is a noop", func() {
+			cb.Close()
+		})
+	})
+}
+
+func TestAbandonedSingleTest(t *testing.T) {
+	Convey("when a half open circuit lets through an execution the pool rejects", t, func() {
+		defer Flush()
+		ConfigureCommand("half_open", CommandConfig{MaxConcurrentRequests: 1})
+
+		cb, _, err := GetCircuit("half_open")
+		So(err, ShouldBeNil)
+		So(cb.forceState(CircuitHalfOpen), ShouldBeNil)
+
+		ticket := <-cb.executorPool.Tickets
+		err = DoC(context.Background(), "half_open", func(ctx context.Context) error {
+			return nil
+		}, nil)
+		So(err, ShouldResemble, ErrMaxConcurrency)
+		cb.executorPool.Return(ticket)
+
+		Convey("the next execution tests the circuit in its place", func() {
+			So(cb.AllowRequest(), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,78 @@
+// Package clock abstracts the passage of time for circuits and their rolling
+// metrics, so that tests can drive sleep windows, rolling windows and command
+// timeouts with a Fake clock instead of sleeping.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time and creates timers.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is the subset of *time.Timer which circuits use.
+type Timer interface {
+	// C returns the channel the time is sent on when the timer fires.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing, returning false if it already has.
+	Stop() bool
+}
+
+var (
+	defaultMutex *sync.RWMutex
+	defaultClock Clock
+)
+
+func init() {
+	defaultMutex = &sync.RWMutex{}
+	defaultClock = Real()
+}
+
+// Default returns the clock given to circuits and rolling metrics as they are created.
+func Default() Clock {
+	defaultMutex.RLock()
+	defer defaultMutex.RUnlock()
+
+	return defaultClock
+}
+
+// SetDefault replaces the clock given to circuits and rolling metrics as they
+// are created. Circuits which already exist keep their clock, so set it before
+// executing commands, or call hystrix.Flush afterwards.
+func SetDefault(c Clock) {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+
+	defaultClock = c
+}
+
+type realClock struct{}
+
+// Real returns a Clock backed by the time package.
+func Real() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.timer.Stop()
+}
@@ -0,0 +1,82 @@
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithTimeout returns a copy of parent which is cancelled once c has moved d
+// past now, like context.WithTimeout does in real time.
+func WithTimeout(parent context.Context, c Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if c == Real() {
+		return context.WithTimeout(parent, d)
+	}
+
+	inner, cancel := context.WithCancel(parent)
+	ctx := &timerCtx{
+		Context:  inner,
+		cancel:   cancel,
+		clock:    c,
+		mutex:    &sync.Mutex{},
+		deadline: c.Now().Add(d),
+	}
+
+	timer := c.NewTimer(d)
+	go func() {
+		select {
+		case <-timer.C():
+			ctx.expire()
+		case <-inner.Done():
+			timer.Stop()
+		}
+	}()
+
+	return ctx, func() {
+		timer.Stop()
+		cancel()
+	}
+}
+
+// timerCtx is a context cancelled by a Clock's timer rather than a real one.
+// Err also checks the deadline itself, so that it is exceeded as soon as the
+// clock has moved past it, without waiting for the timer's goroutine.
+type timerCtx struct {
+	context.Context
+	cancel   context.CancelFunc
+	clock    Clock
+	mutex    *sync.Mutex
+	deadline time.Time
+	err      error
+}
+
+func (ctx *timerCtx) expire() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.err == nil && ctx.Context.Err() == nil {
+		ctx.err = context.DeadlineExceeded
+		ctx.cancel()
+	}
+}
+
+func (ctx *timerCtx) Deadline() (time.Time, bool) {
+	if deadline, ok := ctx.Context.Deadline(); ok && deadline.Before(ctx.deadline) {
+		return deadline, true
+	}
+	return ctx.deadline, true
+}
+
+func (ctx *timerCtx) Err() error {
+	if !ctx.clock.Now().Before(ctx.deadline) {
+		ctx.expire()
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.err != nil {
+		return ctx.err
+	}
+	return ctx.Context.Err()
+}
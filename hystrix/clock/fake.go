@@ -0,0 +1,119 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock which only moves when told to. Its timers fire as Advance or
+// Set moves it past their deadlines.
+type Fake struct {
+	mutex  *sync.Mutex
+	added  *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFake returns a Fake clock set to now.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{}
+	f.mutex = &sync.Mutex{}
+	f.added = sync.NewCond(f.mutex)
+	f.now = now
+
+	return f
+}
+
+// Now returns the clock's current time.
+func (f *Fake) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.now
+}
+
+// NewTimer returns a timer which fires once the clock has moved d past now.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	t := &fakeTimer{
+		clock:    f,
+		deadline: f.now.Add(d),
+		c:        make(chan time.Time, 1),
+	}
+	if d <= 0 {
+		t.c <- f.now
+		t.fired = true
+		return t
+	}
+	f.timers = append(f.timers, t)
+	f.added.Broadcast()
+
+	return t
+}
+
+// WaitForTimers blocks until at least n timers are waiting to fire. Commands
+// create their timers on other goroutines, so call it before Advance to be sure
+// the timers it should fire exist.
+func (f *Fake) WaitForTimers(n int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for len(f.timers) < n {
+		f.added.Wait()
+	}
+}
+
+// Advance moves the clock forward by d, firing any timers which are then due.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to now, firing any timers which are then due.
+func (f *Fake) Set(now time.Time) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.now = now
+
+	pending := f.timers[:0]
+	for _, t := range f.timers {
+		if now.Before(t.deadline) {
+			pending = append(pending, t)
+			continue
+		}
+		t.c <- now
+		t.fired = true
+	}
+	f.timers = pending
+}
+
+type fakeTimer struct {
+	clock    *Fake
+	deadline time.Time
+	c        chan time.Time
+	fired    bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	if t.fired {
+		return false
+	}
+	for i, pending := range t.clock.timers {
+		if pending == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			break
+		}
+	}
+	t.fired = true
+
+	return true
+}
@@ -0,0 +1,96 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFake(t *testing.T) {
+	Convey("with a fake clock", t, func() {
+		start := time.Unix(1000, 0)
+		f := NewFake(start)
+
+		Convey("it only moves when advanced", func() {
+			So(f.Now(), ShouldEqual, start)
+			f.Advance(time.Second)
+			So(f.Now(), ShouldEqual, start.Add(time.Second))
+		})
+
+		Convey("a timer fires once its deadline is reached", func() {
+			timer := f.NewTimer(2 * time.Second)
+
+			f.Advance(time.Second)
+			select {
+			case <-timer.C():
+				t.Fatal("timer fired early")
+			default:
+			}
+
+			f.Advance(time.Second)
+			So(<-timer.C(), ShouldEqual, start.Add(2*time.Second))
+			So(timer.Stop(), ShouldBeFalse)
+		})
+
+		Convey("a stopped timer never fires", func() {
+			timer := f.NewTimer(time.Second)
+			So(timer.Stop(), ShouldBeTrue)
+
+			f.Advance(time.Second)
+			select {
+			case <-timer.C():
+				t.Fatal("stopped timer fired")
+			default:
+			}
+		})
+
+		Convey("WaitForTimers returns once timers created elsewhere exist", func() {
+			go f.NewTimer(time.Second)
+			f.WaitForTimers(1)
+		})
+	})
+}
+
+func TestWithTimeout(t *testing.T) {
+	Convey("with a context timed by a fake clock", t, func() {
+		start := time.Unix(1000, 0)
+		f := NewFake(start)
+		ctx, cancel := WithTimeout(context.Background(), f, time.Second)
+		defer cancel()
+
+		Convey("its deadline is taken from the clock", func() {
+			deadline, ok := ctx.Deadline()
+			So(ok, ShouldBeTrue)
+			So(deadline, ShouldEqual, start.Add(time.Second))
+		})
+
+		Convey("it is only done once the clock reaches the deadline", func() {
+			f.Advance(time.Second - time.Nanosecond)
+			So(ctx.Err(), ShouldBeNil)
+
+			f.Advance(time.Nanosecond)
+			So(ctx.Err(), ShouldResemble, context.DeadlineExceeded)
+			<-ctx.Done()
+		})
+
+		Convey("cancelling it stops its timer", func() {
+			cancel()
+			<-ctx.Done()
+			So(ctx.Err(), ShouldResemble, context.Canceled)
+
+			f.Advance(time.Second)
+			So(ctx.Err(), ShouldResemble, context.Canceled)
+		})
+	})
+
+	Convey("with the real clock it is an ordinary timeout", t, func() {
+		ctx, cancel := WithTimeout(context.Background(), Real(), time.Hour)
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		So(ok, ShouldBeTrue)
+		So(deadline, ShouldHappenAfter, time.Now())
+	})
+}
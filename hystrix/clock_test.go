@@ -0,0 +1,84 @@
+package hystrix
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+	"github.com/afex/hystrix-go/hystrix/metric_collector"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFakeClock(t *testing.T) {
+	Convey("with circuits driven by a fake clock", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		defer Flush()
+
+		ConfigureCommand("", CommandConfig{Timeout: 1000, SleepWindow: 5000})
+
+		Convey("a command times out when the clock passes its timeout", func() {
+			release := make(chan struct{})
+			defer close(release)
+
+			errChan := GoC(context.Background(), "", func(ctx context.Context) error {
+				<-release
+				return nil
+			}, nil)
+
+			fake.WaitForTimers(1)
+			fake.Advance(time.Second)
+			So(<-errChan, ShouldResemble, ErrTimeout)
+		})
+
+		Convey("an open circuit allows a single test once the clock passes its sleep window", func() {
+			cb, _, _ := GetCircuit("")
+			cb.setOpen()
+
+			So(cb.AllowRequest(), ShouldBeFalse)
+			fake.Advance(5 * time.Second)
+			So(cb.AllowRequest(), ShouldBeFalse)
+			fake.Advance(time.Millisecond)
+			So(cb.AllowRequest(), ShouldBeTrue)
+			So(cb.AllowRequest(), ShouldBeFalse)
+		})
+
+		Convey("deadline admission compares deadlines with the clock", func() {
+			ConfigureCommand("", CommandConfig{DeadlineAdmissionPercentile: 50})
+			fake.Advance(time.Hour)
+			cb, _, _ := GetCircuit("")
+			cb.metrics.DefaultCollector().Update(metricCollector.MetricResult{Attempts: 1, RunStart: fake.Now(), RunDuration: 50 * time.Millisecond})
+
+			tooClose, cancel := context.WithDeadline(context.Background(), fake.Now().Add(40*time.Millisecond))
+			defer cancel()
+			So(cb.hasTimeFor(tooClose), ShouldBeFalse)
+
+			farEnough, cancel := context.WithDeadline(context.Background(), fake.Now().Add(60*time.Millisecond))
+			defer cancel()
+			So(cb.hasTimeFor(farEnough), ShouldBeTrue)
+		})
+
+		Convey("metrics roll with the clock", func() {
+			cb, _, _ := GetCircuit("")
+			cb.metrics.DefaultCollector().Update(metricCollector.MetricResult{Attempts: 3})
+
+			So(cb.metrics.Requests().Sum(fake.Now()), ShouldEqual, 3)
+			fake.Advance(11 * time.Second)
+			So(cb.metrics.Requests().Sum(fake.Now()), ShouldEqual, 0)
+		})
+
+		Convey("metrics keep the circuit's clock when they are reset", func() {
+			cb, _, _ := GetCircuit("")
+			clock.SetDefault(clock.Real())
+			fake.Advance(time.Hour)
+			cb.metrics.Reset()
+			cb.metrics.DefaultCollector().Update(metricCollector.MetricResult{Attempts: 3})
+
+			So(cb.metrics.Requests().Sum(fake.Now()), ShouldEqual, 3)
+			fake.Advance(11 * time.Second)
+			So(cb.metrics.Requests().Sum(fake.Now()), ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,34 @@
+package hystrix
+
+import "context"
+
+// Criticality describes how important an execution is, so that less important
+// work can be shed first when a command's pool is saturated.
+type Criticality string
+
+const (
+	// CriticalityCritical executions may use every ticket in the pool.
+	CriticalityCritical Criticality = "CRITICAL"
+	// CriticalityDefault executions may not use the tickets reserved for critical work.
+	// It applies to any execution without an explicit criticality.
+	CriticalityDefault Criticality = "DEFAULT"
+	// CriticalitySheddable executions are the first to be rejected as the pool fills up.
+	CriticalitySheddable Criticality = "SHEDDABLE"
+)
+
+type criticalityContextKey struct{}
+
+// WithCriticality returns a context whose commands are admitted according to
+// the given criticality once their pool nears saturation. See
+// CommandConfig.PriorityReservePercent.
+func WithCriticality(ctx context.Context, c Criticality) context.Context {
+	return context.WithValue(ctx, criticalityContextKey{}, c)
+}
+
+func criticalityFromContext(ctx context.Context) Criticality {
+	c, ok := ctx.Value(criticalityContextKey{}).(Criticality)
+	if !ok {
+		return CriticalityDefault
+	}
+	return c
+}
@@ -78,7 +78,7 @@ func (sh *StreamHandler) loop() {
 			circuitBreakersMutex.RLock()
 			for _, cb := range circuitBreakers {
 				sh.publishMetrics(cb)
-				sh.publishThreadPools(cb.executorPool)
+				sh.publishThreadPools(cb.executorPool, cb.clock.Now())
 			}
 			circuitBreakersMutex.RUnlock()
 		case <-sh.done:
@@ -88,10 +88,27 @@ func (sh *StreamHandler) loop() {
 }
 
 func (sh *StreamHandler) publishMetrics(cb *CircuitBreaker) error {
-	now := time.Now()
+	now := cb.clock.Now()
 	reqCount := cb.metrics.Requests().Sum(now)
 	errCount := cb.metrics.DefaultCollector().Errors().Sum(now)
 	errPct := cb.metrics.ErrorPercent(now)
+	settings := getSettings(cb.Name)
+
+	// each timing's statistics are taken from a single snapshot, so that they
+	// agree with each other.
+	totalDuration := cb.metrics.DefaultCollector().TotalDuration().Snapshot(now)
+	runDuration := cb.metrics.DefaultCollector().RunDuration().Snapshot(now)
+	monitorLag := cb.metrics.DefaultCollector().MonitorLag().Snapshot(now)
+
+	// rejections come from the pool's tickets either way, so attribute them to
+	// whichever the command is isolated by.
+	rejected := uint32(cb.metrics.DefaultCollector().Rejects().Sum(now))
+	var threadPoolRejected, semaphoreRejected uint32
+	if settings.ExecutionIsolationStrategy == IsolationSemaphore {
+		semaphoreRejected = rejected
+	} else {
+		threadPoolRejected = rejected
+	}
 
 	eventBytes, err := json.Marshal(&streamCmdMetric{
 		Type:           "HystrixCommand",
@@ -104,31 +121,48 @@ func (sh *StreamHandler) publishMetrics(cb *CircuitBreaker) error {
 		ErrorCount:         uint32(errCount),
 		ErrorPct:           uint32(errPct),
 		CircuitBreakerOpen: cb.IsOpen(),
-
-		RollingCountSuccess:            uint32(cb.metrics.DefaultCollector().Successes().Sum(now)),
-		RollingCountFailure:            uint32(cb.metrics.DefaultCollector().Failures().Sum(now)),
-		RollingCountThreadPoolRejected: uint32(cb.metrics.DefaultCollector().Rejects().Sum(now)),
-		RollingCountShortCircuited:     uint32(cb.metrics.DefaultCollector().ShortCircuits().Sum(now)),
-		RollingCountTimeout:            uint32(cb.metrics.DefaultCollector().Timeouts().Sum(now)),
-		RollingCountFallbackSuccess:    uint32(cb.metrics.DefaultCollector().FallbackSuccesses().Sum(now)),
-		RollingCountFallbackFailure:    uint32(cb.metrics.DefaultCollector().FallbackFailures().Sum(now)),
-
-		LatencyTotal:       generateLatencyTimings(cb.metrics.DefaultCollector().TotalDuration()),
-		LatencyTotalMean:   cb.metrics.DefaultCollector().TotalDuration().Mean(),
-		LatencyExecute:     generateLatencyTimings(cb.metrics.DefaultCollector().RunDuration()),
-		LatencyExecuteMean: cb.metrics.DefaultCollector().RunDuration().Mean(),
+		SlowStartPct:       uint32(cb.slowStartPercent(now)),
+
+		RollingCountSuccess:              uint32(cb.metrics.DefaultCollector().Successes().Sum(now)),
+		RollingCountFailure:              uint32(cb.metrics.DefaultCollector().Failures().Sum(now)),
+		RollingCountThreadPoolRejected:   threadPoolRejected,
+		RollingCountSemaphoreRejected:    semaphoreRejected,
+		RollingCountShortCircuited:       uint32(cb.metrics.DefaultCollector().ShortCircuits().Sum(now)),
+		RollingCountTimeout:              uint32(cb.metrics.DefaultCollector().Timeouts().Sum(now)),
+		RollingCountRateLimited:          uint32(cb.metrics.DefaultCollector().RateLimited().Sum(now)),
+		RollingCountInsufficientDeadline: uint32(cb.metrics.DefaultCollector().InsufficientDeadline().Sum(now)),
+		RollingCountSlowStartRejected:    uint32(cb.metrics.DefaultCollector().SlowStartRejects().Sum(now)),
+		RollingCountShed:                 uint32(cb.metrics.DefaultCollector().Shed().Sum(now)),
+		RollingCountFallbackSuccess:      uint32(cb.metrics.DefaultCollector().FallbackSuccesses().Sum(now)),
+		RollingCountFallbackFailure:      uint32(cb.metrics.DefaultCollector().FallbackFailures().Sum(now)),
+		RollingCountFallbackStale:        uint32(cb.metrics.DefaultCollector().FallbackStale().Sum(now)),
+		RollingCountFaultInjected:        uint32(cb.metrics.DefaultCollector().FaultInjected().Sum(now)),
+		RollingCountShadowShortCircuited: uint32(cb.metrics.DefaultCollector().ShadowShortCircuits().Sum(now)),
+		RollingCountShadowRejected:       uint32(cb.metrics.DefaultCollector().ShadowRejects().Sum(now)),
+		RollingCountShadowTimeout:        uint32(cb.metrics.DefaultCollector().ShadowTimeouts().Sum(now)),
+		RollingCountDroppedMetrics:       uint32(cb.metrics.DefaultCollector().DroppedUpdates().Sum(now)),
+		RollingCountRejectedByKey:        rejectsByKey(cb.metrics.DefaultCollector().RejectsByKey(now)),
+
+		LatencyTotal:       generateLatencyTimings(totalDuration),
+		LatencyTotalMean:   milliseconds(totalDuration.Mean),
+		LatencyExecute:     generateLatencyTimings(runDuration),
+		LatencyExecuteMean: milliseconds(runDuration.Mean),
+		MetricsLagMean:     milliseconds(monitorLag.Mean),
+		MetricsLagMax:      milliseconds(monitorLag.Max),
 
 		// TODO: all hard-coded values should become configurable settings, per circuit
 
-		RollingStatsWindow:         10000,
-		ExecutionIsolationStrategy: "THREAD",
+		RollingStatsWindow: uint32(cb.metrics.DefaultCollector().NumRequests().Window().Length / time.Millisecond),
+
+		ExecutionIsolationStrategy:                       settings.ExecutionIsolationStrategy,
+		ExecutionIsolationSemaphoreMaxConcurrentRequests: uint32(settings.MaxConcurrentRequests),
 
 		CircuitBreakerEnabled:                true,
 		CircuitBreakerForceClosed:            false,
 		CircuitBreakerForceOpen:              cb.forceOpen,
-		CircuitBreakerErrorThresholdPercent:  uint32(getSettings(cb.Name).ErrorPercentThreshold),
-		CircuitBreakerSleepWindow:            uint32(getSettings(cb.Name).SleepWindow.Seconds() * 1000),
-		CircuitBreakerRequestVolumeThreshold: uint32(getSettings(cb.Name).RequestVolumeThreshold),
+		CircuitBreakerErrorThresholdPercent:  uint32(settings.ErrorPercentThreshold),
+		CircuitBreakerSleepWindow:            uint32(settings.SleepWindow.Seconds() * 1000),
+		CircuitBreakerRequestVolumeThreshold: uint32(settings.RequestVolumeThreshold),
 	})
 	if err != nil {
 		return err
@@ -141,9 +175,7 @@ func (sh *StreamHandler) publishMetrics(cb *CircuitBreaker) error {
 	return nil
 }
 
-func (sh *StreamHandler) publishThreadPools(pool *executorPool) error {
-	now := time.Now()
-
+func (sh *StreamHandler) publishThreadPools(pool *executorPool, now time.Time) error {
 	eventBytes, err := json.Marshal(&streamThreadPoolMetric{
 		Type:           "HystrixThreadPool",
 		Name:           pool.Name,
@@ -161,7 +193,7 @@ func (sh *StreamHandler) publishThreadPools(pool *executorPool) error {
 		CurrentLargestPoolSize: uint32(pool.Max),
 		CurrentMaximumPoolSize: uint32(pool.Max),
 
-		RollingStatsWindow:          10000,
+		RollingStatsWindow:          uint32(pool.Metrics.Executed.Window().Length / time.Millisecond),
 		QueueSizeRejectionThreshold: 0,
 		CurrentQueueSize:            0,
 	})
@@ -223,18 +255,37 @@ func (sh *StreamHandler) unregister(req *http.Request) {
 	sh.mu.Unlock()
 }
 
-func generateLatencyTimings(r *rolling.Timing) streamCmdLatency {
+func generateLatencyTimings(s *rolling.TimingSnapshot) streamCmdLatency {
 	return streamCmdLatency{
-		Timing0:   r.Percentile(0),
-		Timing25:  r.Percentile(25),
-		Timing50:  r.Percentile(50),
-		Timing75:  r.Percentile(75),
-		Timing90:  r.Percentile(90),
-		Timing95:  r.Percentile(95),
-		Timing99:  r.Percentile(99),
-		Timing995: r.Percentile(99.5),
-		Timing100: r.Percentile(100),
+		Timing0:   milliseconds(s.Percentile(0)),
+		Timing25:  milliseconds(s.Percentile(25)),
+		Timing50:  milliseconds(s.Percentile(50)),
+		Timing75:  milliseconds(s.Percentile(75)),
+		Timing90:  milliseconds(s.Percentile(90)),
+		Timing95:  milliseconds(s.Percentile(95)),
+		Timing99:  milliseconds(s.Percentile(99)),
+		Timing995: milliseconds(s.Percentile(99.5)),
+		Timing100: milliseconds(s.Percentile(100)),
+	}
+}
+
+// milliseconds returns the duration in whole milliseconds, as the dashboard expects.
+func milliseconds(d time.Duration) uint32 {
+	return uint32(d / time.Millisecond)
+}
+
+// rejectsByKey converts the rejections per bulkhead key to counts, leaving
+// commands without any out of the stream.
+func rejectsByKey(rejects map[string]float64) map[string]uint32 {
+	if len(rejects) == 0 {
+		return nil
 	}
+
+	counts := make(map[string]uint32, len(rejects))
+	for key, n := range rejects {
+		counts[key] = uint32(n)
+	}
+	return counts
 }
 
 type streamCmdMetric struct {
@@ -249,6 +300,7 @@ type streamCmdMetric struct {
 	ErrorCount         uint32 `json:"errorCount"`
 	ErrorPct           uint32 `json:"errorPercentage"`
 	CircuitBreakerOpen bool   `json:"isCircuitBreakerOpen"`
+	SlowStartPct       uint32 `json:"slowStartPercentage"`
 
 	RollingCountCollapsedRequests  uint32 `json:"rollingCountCollapsedRequests"`
 	RollingCountExceptionsThrown   uint32 `json:"rollingCountExceptionsThrown"`
@@ -263,12 +315,27 @@ type streamCmdMetric struct {
 	RollingCountThreadPoolRejected uint32 `json:"rollingCountThreadPoolRejected"`
 	RollingCountTimeout            uint32 `json:"rollingCountTimeout"`
 
+	RollingCountRateLimited          uint32 `json:"rollingCountRateLimited"`
+	RollingCountInsufficientDeadline uint32 `json:"rollingCountInsufficientDeadline"`
+	RollingCountSlowStartRejected    uint32 `json:"rollingCountSlowStartRejected"`
+	RollingCountShed                 uint32 `json:"rollingCountShed"`
+	RollingCountFallbackStale        uint32 `json:"rollingCountFallbackStale"`
+	RollingCountFaultInjected        uint32 `json:"rollingCountFaultInjected"`
+	RollingCountShadowShortCircuited uint32 `json:"rollingCountShadowShortCircuited"`
+	RollingCountShadowRejected       uint32 `json:"rollingCountShadowRejected"`
+	RollingCountShadowTimeout        uint32 `json:"rollingCountShadowTimeout"`
+	RollingCountDroppedMetrics       uint32 `json:"rollingCountDroppedMetrics"`
+
+	RollingCountRejectedByKey map[string]uint32 `json:"rollingCountRejectedByBulkheadKey,omitempty"`
+
 	CurrentConcurrentExecutionCount uint32 `json:"currentConcurrentExecutionCount"`
 
 	LatencyExecuteMean uint32           `json:"latencyExecute_mean"`
 	LatencyExecute     streamCmdLatency `json:"latencyExecute"`
 	LatencyTotalMean   uint32           `json:"latencyTotal_mean"`
 	LatencyTotal       streamCmdLatency `json:"latencyTotal"`
+	MetricsLagMean     uint32           `json:"metricsLag_mean"`
+	MetricsLagMax      uint32           `json:"metricsLag_max"`
 
 	// Properties
 	CircuitBreakerRequestVolumeThreshold             uint32 `json:"propertyValue_circuitBreakerRequestVolumeThreshold"`
@@ -1,6 +1,7 @@
 package hystrix
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -178,6 +179,73 @@ func TestEventStream(t *testing.T) {
 	})
 }
 
+func TestSemaphoreEventStream(t *testing.T) {
+	Convey("given a running event stream", t, func() {
+		server := startTestServer()
+		defer server.stopTestServer()
+
+		Convey("after a command isolated by semaphore is rejected", func() {
+			ConfigureCommand("semaphore", CommandConfig{ExecutionIsolationStrategy: IsolationSemaphore, MaxConcurrentRequests: 1})
+			cb, _, _ := GetCircuit("semaphore")
+			ticket := <-cb.executorPool.Tickets
+			Do("semaphore", func() error { return nil }, nil)
+			cb.executorPool.Return(ticket)
+
+			Convey("the strategy and the rejection are reported as semaphore", func() {
+				metric := grabFirstCommandFromStream(t, server.URL)
+
+				So(metric.ExecutionIsolationStrategy, ShouldEqual, IsolationSemaphore)
+				So(metric.RollingCountSemaphoreRejected, ShouldEqual, 1)
+				So(metric.RollingCountThreadPoolRejected, ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestBulkheadKeyEventStream(t *testing.T) {
+	Convey("given a running event stream", t, func() {
+		server := startTestServer()
+		defer server.stopTestServer()
+
+		Convey("after an execution is rejected for its bulkhead key", func() {
+			ConfigureCommand("keyed", CommandConfig{MaxConcurrentRequestsPerKey: 1})
+			ctx := WithBulkheadKey(context.Background(), "noisy")
+
+			started, release := make(chan struct{}), make(chan struct{})
+			GoC(ctx, "keyed", func(ctx context.Context) error {
+				close(started)
+				<-release
+				return nil
+			}, nil)
+			<-started
+			So(DoC(ctx, "keyed", func(ctx context.Context) error { return nil }, nil), ShouldResemble, ErrKeyMaxConcurrency)
+			close(release)
+
+			Convey("the rejection is attributed to the key", func() {
+				metric := grabFirstCommandFromStream(t, server.URL)
+				So(metric.RollingCountRejectedByKey, ShouldResemble, map[string]uint32{"noisy": 1})
+			})
+		})
+	})
+}
+
+func TestRollingWindowEventStream(t *testing.T) {
+	Convey("given a running event stream", t, func() {
+		server := startTestServer()
+		defer server.stopTestServer()
+
+		Convey("after a command with a 20 second statistical window", func() {
+			ConfigureCommand("window", CommandConfig{MetricsRollingStatisticalWindow: 20000, MetricsRollingStatisticalWindowBuckets: 20})
+			sleepingCommand(t, "window", 1*time.Millisecond)
+
+			Convey("the window is reported for the command and its pool", func() {
+				So(grabFirstCommandFromStream(t, server.URL).RollingStatsWindow, ShouldEqual, 20000)
+				So(grabFirstThreadPoolFromStream(t, server.URL).RollingStatsWindow, ShouldEqual, 20000)
+			})
+		})
+	})
+}
+
 func TestClientCancelEventStream(t *testing.T) {
 	Convey("given a running event stream", t, func() {
 		server := startTestServer()
@@ -0,0 +1,143 @@
+package hystrix
+
+import (
+	"sync"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+)
+
+// EvictionConfig bounds how many circuits are kept in memory. Circuits are
+// created on first use, so commands with high-cardinality names would otherwise
+// accumulate circuits, and their monitoring goroutines, forever.
+type EvictionConfig struct {
+	// IdleTTL removes circuits which have not been used for this long. Zero disables idle eviction.
+	IdleTTL time.Duration
+	// MaxCircuits caps the number of circuits. Creating a circuit beyond the cap
+	// removes the least recently used one first. Zero means no cap.
+	MaxCircuits int
+}
+
+var (
+	evictionMutex  *sync.Mutex
+	evictionConfig EvictionConfig
+	evictionClock  clock.Clock
+	evictionDone   chan struct{}
+)
+
+func init() {
+	evictionMutex = &sync.Mutex{}
+}
+
+// ConfigureEviction applies eviction settings to all circuits. Circuits with
+// commands in flight are never evicted, so MaxCircuits may be exceeded while
+// every circuit is busy. Idle circuits are looked for with the default clock
+// at the time of the call.
+func ConfigureEviction(config EvictionConfig) {
+	configureEviction(config, clock.Default())
+}
+
+func configureEviction(config EvictionConfig, clk clock.Clock) {
+	evictionMutex.Lock()
+	defer evictionMutex.Unlock()
+
+	if evictionDone != nil {
+		close(evictionDone)
+		evictionDone = nil
+	}
+
+	evictionConfig = config
+	evictionClock = clk
+
+	if config.IdleTTL > 0 {
+		evictionDone = make(chan struct{})
+		go evictIdleCircuitsLoop(config.IdleTTL, clk, evictionDone)
+	}
+}
+
+func getEvictionConfig() EvictionConfig {
+	evictionMutex.Lock()
+	defer evictionMutex.Unlock()
+
+	return evictionConfig
+}
+
+// getEviction returns the eviction config along with the clock its idle
+// circuits are looked for with.
+func getEviction() (EvictionConfig, clock.Clock) {
+	evictionMutex.Lock()
+	defer evictionMutex.Unlock()
+
+	return evictionConfig, evictionClock
+}
+
+func evictIdleCircuitsLoop(ttl time.Duration, clk clock.Clock, done chan struct{}) {
+	interval := ttl / 2
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+
+	for {
+		timer := clk.NewTimer(interval)
+		select {
+		case <-timer.C():
+			evictIdleCircuits(clk.Now(), ttl)
+		case <-done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// evictIdleCircuits removes every circuit which has been unused for longer than ttl.
+func evictIdleCircuits(now time.Time, ttl time.Duration) {
+	var evicted []*CircuitBreaker
+
+	circuitBreakersMutex.Lock()
+	for name, cb := range circuitBreakers {
+		if !cb.inUse() && now.Sub(cb.lastUsed()) > ttl {
+			evicted = append(evicted, cb)
+			delete(circuitBreakers, name)
+		}
+	}
+	circuitBreakersMutex.Unlock()
+
+	for _, cb := range evicted {
+		log.Log(LogLevelInfo, "evicting idle circuit", LogField{"circuit", cb.Name})
+		cb.Close()
+	}
+}
+
+// evictForCapacityLocked removes least recently used idle circuits until there
+// is room for one more within the configured cap. The caller must hold
+// circuitBreakersMutex and is responsible for closing the returned circuits
+// once it is released.
+func evictForCapacityLocked() []*CircuitBreaker {
+	max := getEvictionConfig().MaxCircuits
+	if max <= 0 {
+		return nil
+	}
+
+	var evicted []*CircuitBreaker
+	for len(circuitBreakers) >= max {
+		var oldest *CircuitBreaker
+		for _, cb := range circuitBreakers {
+			if cb.inUse() {
+				continue
+			}
+			if oldest == nil || cb.lastUsed().Before(oldest.lastUsed()) {
+				oldest = cb
+			}
+		}
+		if oldest == nil {
+			break
+		}
+
+		log.Log(LogLevelInfo, "evicting circuit to stay within max circuits",
+			LogField{"circuit", oldest.Name}, LogField{"max_circuits", max})
+		delete(circuitBreakers, oldest.Name)
+		evicted = append(evicted, oldest)
+	}
+
+	return evicted
+}
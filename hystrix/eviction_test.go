@@ -0,0 +1,128 @@
+package hystrix
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEvictIdleCircuits(t *testing.T) {
+	Convey("with one idle and one recently used circuit", t, func() {
+		defer Flush()
+
+		idle, _, _ := GetCircuit("idle")
+		GetCircuit("busy")
+		idle.lastUsedTime = time.Now().Add(-time.Minute).UnixNano()
+
+		Convey("evicting with a ttl of 30 seconds removes only the idle circuit", func() {
+			evictIdleCircuits(time.Now(), 30*time.Second)
+
+			circuitBreakersMutex.RLock()
+			_, idleOk := circuitBreakers["idle"]
+			_, busyOk := circuitBreakers["busy"]
+			circuitBreakersMutex.RUnlock()
+
+			So(idleOk, ShouldBeFalse)
+			So(busyOk, ShouldBeTrue)
+		})
+
+		Convey("a circuit held by a command in flight is kept", func() {
+			held, _ := acquireCircuit("idle")
+			defer held.release()
+
+			evictIdleCircuits(time.Now(), 30*time.Second)
+
+			circuitBreakersMutex.RLock()
+			_, idleOk := circuitBreakers["idle"]
+			circuitBreakersMutex.RUnlock()
+
+			So(idleOk, ShouldBeTrue)
+		})
+	})
+
+	Convey("with an idle ttl configured", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		defer Flush()
+		ConfigureEviction(EvictionConfig{IdleTTL: 20 * time.Millisecond})
+		defer ConfigureEviction(EvictionConfig{})
+
+		GetCircuit("foo")
+
+		Convey("unused circuits are removed in the background", func() {
+			// the loop looks every 10ms, and sets its next timer once it has looked
+			fake.WaitForTimers(1)
+			fake.Advance(25 * time.Millisecond)
+			fake.WaitForTimers(1)
+
+			circuitBreakersMutex.RLock()
+			_, ok := circuitBreakers["foo"]
+			circuitBreakersMutex.RUnlock()
+
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
+
+func TestMaxCircuits(t *testing.T) {
+	Convey("with a cap of 2 circuits", t, func() {
+		defer Flush()
+		ConfigureEviction(EvictionConfig{MaxCircuits: 2})
+		defer ConfigureEviction(EvictionConfig{})
+
+		first, _, _ := GetCircuit("first")
+		second, _, _ := GetCircuit("second")
+		first.lastUsedTime = second.lastUsedTime + 1
+
+		Convey("creating a third evicts the least recently used", func() {
+			GetCircuit("third")
+
+			circuitBreakersMutex.RLock()
+			defer circuitBreakersMutex.RUnlock()
+			So(len(circuitBreakers), ShouldEqual, 2)
+			_, ok := circuitBreakers["second"]
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("a circuit held by a command in flight is not evicted", func() {
+			held, _ := acquireCircuit("second")
+			GetCircuit("third")
+
+			circuitBreakersMutex.RLock()
+			_, ok := circuitBreakers["second"]
+			circuitBreakersMutex.RUnlock()
+			So(ok, ShouldBeTrue)
+
+			Convey("and the command's metrics still reach it", func() {
+				So(held.ReportEvent([]string{"success"}, time.Now(), 0), ShouldBeNil)
+				held.release()
+				held.metrics.Close()
+				So(held.metrics.DefaultCollector().Successes().Sum(time.Now()), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestCircuitHolds(t *testing.T) {
+	Convey("when commands have finished on a circuit", t, func() {
+		defer Flush()
+		ConfigureCommand("held", CommandConfig{})
+
+		// DoC waits for executions to be reported when they have a request log.
+		ctx, _ := WithRequestLog(context.Background())
+		So(DoC(ctx, "held", func(ctx context.Context) error { return nil }, nil), ShouldBeNil)
+		So(DoC(ctx, "held", func(ctx context.Context) error { return fmt.Errorf("failed") }, nil), ShouldNotBeNil)
+		cb, _, _ := GetCircuit("held")
+		cb.setOpen()
+		So(DoC(ctx, "held", func(ctx context.Context) error { return nil }, nil), ShouldResemble, ErrCircuitOpen)
+
+		Convey("they no longer hold it", func() {
+			So(cb.inUse(), ShouldBeFalse)
+		})
+	})
+}
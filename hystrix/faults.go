@@ -0,0 +1,199 @@
+package hystrix
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// FaultScope selects the executions a fault applies to.
+type FaultScope struct {
+	// Percent of executions, from 0 to 100, the fault applies to.
+	Percent float64
+	// ContextOnly limits the fault to executions whose context was marked by
+	// WithFaultInjection. Percent still applies within them.
+	ContextOnly bool
+}
+
+const (
+	// LatencyUniform adds a delay of up to Jitter, all equally likely.
+	LatencyUniform = "UNIFORM"
+	// LatencyNormal varies the delay around Delay, with Jitter as the standard deviation.
+	LatencyNormal = "NORMAL"
+	// LatencyExponential adds a delay averaging Jitter, usually short but with
+	// the long tail real latencies tend to have.
+	LatencyExponential = "EXPONENTIAL"
+)
+
+// LatencyFault delays executions before run.
+type LatencyFault struct {
+	FaultScope
+	// Delay is added to every affected execution. Without Jitter the delay is fixed.
+	Delay time.Duration
+	// Jitter is how much the delay varies, as drawn from Distribution.
+	Jitter time.Duration
+	// Distribution is LatencyUniform, LatencyNormal or LatencyExponential. Defaults to LatencyUniform.
+	Distribution string
+}
+
+// delay draws the delay for an affected execution, which is never negative.
+func (f LatencyFault) delay() time.Duration {
+	if f.Jitter <= 0 {
+		return f.Delay
+	}
+
+	var jitter float64
+	switch f.Distribution {
+	case LatencyNormal:
+		jitter = rand.NormFloat64() * float64(f.Jitter)
+	case LatencyExponential:
+		jitter = rand.ExpFloat64() * float64(f.Jitter)
+	default:
+		jitter = rand.Float64() * float64(f.Jitter)
+	}
+
+	delay := f.Delay + time.Duration(jitter)
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// ErrorFault fails executions with Err instead of running them.
+type ErrorFault struct {
+	FaultScope
+	// Err is the error to fail with, ErrFaultInjected if nil.
+	Err error
+}
+
+// TimeoutFault makes executions wait out the command's Timeout instead of running them.
+type TimeoutFault struct {
+	FaultScope
+}
+
+// FaultConfig describes the failures to inject into a command's executions, to
+// rehearse outages without touching the service behind the command. Faults are
+// applied after an execution is admitted and before run, latency first.
+// Executions with a fault applied are reported with MetricResult.FaultInjected.
+type FaultConfig struct {
+	Latency LatencyFault
+	Error   ErrorFault
+	Timeout TimeoutFault
+}
+
+// ErrFaultInjected is the error executions fail with when an ErrorFault has no Err.
+var ErrFaultInjected = CircuitError{Message: "injected fault"}
+
+var (
+	faultsMutex *sync.RWMutex
+	faults      map[string]FaultConfig
+)
+
+func init() {
+	faultsMutex = &sync.RWMutex{}
+	faults = make(map[string]FaultConfig)
+}
+
+// ConfigureFaults starts injecting faults into executions of the named command,
+// replacing any faults configured before. It takes effect immediately.
+func ConfigureFaults(name string, config FaultConfig) {
+	faultsMutex.Lock()
+	defer faultsMutex.Unlock()
+
+	faults[name] = config
+}
+
+// ClearFaults stops injecting faults into executions of the named command.
+func ClearFaults(name string) {
+	faultsMutex.Lock()
+	defer faultsMutex.Unlock()
+
+	delete(faults, name)
+}
+
+func getFaults(name string) (FaultConfig, bool) {
+	faultsMutex.RLock()
+	defer faultsMutex.RUnlock()
+
+	config, ok := faults[name]
+	return config, ok
+}
+
+type faultInjectionContextKey struct{}
+
+// WithFaultInjection returns a context whose executions are subject to faults
+// scoped with ContextOnly.
+func WithFaultInjection(ctx context.Context) context.Context {
+	return context.WithValue(ctx, faultInjectionContextKey{}, true)
+}
+
+func faultInjectionFromContext(ctx context.Context) bool {
+	enabled, _ := ctx.Value(faultInjectionContextKey{}).(bool)
+	return enabled
+}
+
+// applies decides whether the fault applies to an execution under ctx.
+func (s FaultScope) applies(ctx context.Context) bool {
+	if s.Percent <= 0 {
+		return false
+	}
+	if s.ContextOnly && !faultInjectionFromContext(ctx) {
+		return false
+	}
+	return rand.Float64()*100 < s.Percent
+}
+
+// injectFaults applies the command's configured faults, returning the error to
+// fail the execution with instead of running it, if any.
+func (c *command) injectFaults(ctx context.Context) error {
+	config, ok := getFaults(c.circuit.Name)
+	if !ok {
+		return nil
+	}
+
+	if config.Latency.applies(ctx) {
+		c.markFaultInjected()
+		if err := c.wait(ctx, config.Latency.delay()); err != nil {
+			return err
+		}
+	}
+
+	if config.Error.applies(ctx) {
+		c.markFaultInjected()
+		if config.Error.Err == nil {
+			return ErrFaultInjected
+		}
+		return config.Error.Err
+	}
+
+	if config.Timeout.applies(ctx) {
+		c.markFaultInjected()
+		if err := c.wait(ctx, getSettings(c.circuit.Name).Timeout); err != nil {
+			return err
+		}
+		return ErrTimeout
+	}
+
+	return nil
+}
+
+func (c *command) markFaultInjected() {
+	c.Lock()
+	defer c.Unlock()
+
+	c.faultInjected = true
+}
+
+// wait blocks for d on the circuit's clock, or until ctx is done.
+func (c *command) wait(ctx context.Context, d time.Duration) error {
+	timer := c.circuit.clock.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,182 @@
+package hystrix
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+	"github.com/afex/hystrix-go/hystrix/metric_collector"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// resultRecorder sends the results it receives on results, dropping those
+// nobody has room for.
+type resultRecorder struct {
+	results chan metricCollector.MetricResult
+}
+
+func (r resultRecorder) Update(result metricCollector.MetricResult) {
+	select {
+	case r.results <- result:
+	default:
+	}
+}
+
+func (r resultRecorder) Reset() {}
+
+func TestFaultInjection(t *testing.T) {
+	Convey("with a command which records whether it ran", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		defer Flush()
+		defer ClearFaults("")
+		ConfigureCommand("", CommandConfig{Timeout: 50})
+
+		var ran int32
+		run := func(ctx context.Context) error {
+			atomic.StoreInt32(&ran, 1)
+			return nil
+		}
+
+		Convey("an error fault fails every execution in scope", func() {
+			ConfigureFaults("", FaultConfig{Error: ErrorFault{FaultScope: FaultScope{Percent: 100}, Err: fmt.Errorf("chaos")}})
+			recorder := resultRecorder{results: make(chan metricCollector.MetricResult, 1)}
+			cb, _, _ := GetCircuit("")
+			cb.metrics.addCollector(recorder)
+
+			err := DoC(context.Background(), "", run, nil)
+			So(err.Error(), ShouldEqual, "chaos")
+			So(atomic.LoadInt32(&ran), ShouldEqual, 0)
+
+			Convey("and is recorded as injected", func() {
+				result := <-recorder.results
+				So(result.Failures, ShouldEqual, 1)
+				So(result.FaultInjected, ShouldEqual, 1)
+			})
+
+			Convey("until the faults are cleared", func() {
+				ClearFaults("")
+				So(DoC(context.Background(), "", run, nil), ShouldBeNil)
+				So(atomic.LoadInt32(&ran), ShouldEqual, 1)
+			})
+		})
+
+		Convey("an error fault without an error fails with ErrFaultInjected", func() {
+			ConfigureFaults("", FaultConfig{Error: ErrorFault{FaultScope: FaultScope{Percent: 100}}})
+
+			So(DoC(context.Background(), "", run, nil), ShouldResemble, ErrFaultInjected)
+		})
+
+		Convey("a latency fault delays run", func() {
+			ConfigureFaults("", FaultConfig{Latency: LatencyFault{FaultScope: FaultScope{Percent: 100}, Delay: 20 * time.Millisecond}})
+
+			errChan := make(chan error)
+			go func() { errChan <- DoC(context.Background(), "", run, nil) }()
+
+			// the command's timeout, and the delay
+			fake.WaitForTimers(2)
+			So(atomic.LoadInt32(&ran), ShouldEqual, 0)
+			fake.Advance(20 * time.Millisecond)
+			So(<-errChan, ShouldBeNil)
+			So(atomic.LoadInt32(&ran), ShouldEqual, 1)
+		})
+
+		Convey("a timeout fault makes the execution time out", func() {
+			ConfigureFaults("", FaultConfig{Timeout: TimeoutFault{FaultScope: FaultScope{Percent: 100}}})
+
+			errChan := make(chan error)
+			go func() { errChan <- DoC(context.Background(), "", run, nil) }()
+
+			fake.WaitForTimers(2)
+			fake.Advance(50 * time.Millisecond)
+			So(<-errChan, ShouldResemble, ErrTimeout)
+			So(atomic.LoadInt32(&ran), ShouldEqual, 0)
+		})
+
+		Convey("a fault scoped to marked contexts leaves other executions alone", func() {
+			ConfigureFaults("", FaultConfig{Error: ErrorFault{FaultScope: FaultScope{Percent: 100, ContextOnly: true}}})
+
+			So(DoC(context.Background(), "", run, nil), ShouldBeNil)
+			So(DoC(WithFaultInjection(context.Background()), "", run, nil), ShouldResemble, ErrFaultInjected)
+		})
+
+		Convey("a fault scoped to no traffic is never applied", func() {
+			ConfigureFaults("", FaultConfig{Error: ErrorFault{FaultScope: FaultScope{Percent: 0}}})
+
+			So(DoC(context.Background(), "", run, nil), ShouldBeNil)
+		})
+	})
+
+	Convey("with a command isolated by semaphore", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		defer Flush()
+		defer ClearFaults("")
+		ConfigureCommand("", CommandConfig{Timeout: 20, ExecutionIsolationStrategy: IsolationSemaphore})
+
+		Convey("a timeout fault makes the execution time out", func() {
+			ConfigureFaults("", FaultConfig{Timeout: TimeoutFault{FaultScope: FaultScope{Percent: 100}}})
+
+			errChan := make(chan error)
+			go func() {
+				errChan <- DoC(context.Background(), "", func(ctx context.Context) error {
+					return nil
+				}, nil)
+			}()
+
+			// the context's deadline, and the fault's wait for it
+			fake.WaitForTimers(2)
+			fake.Advance(20 * time.Millisecond)
+			So(<-errChan, ShouldResemble, ErrTimeout)
+		})
+	})
+}
+
+func TestLatencyFaultDistribution(t *testing.T) {
+	Convey("with a delay of 100ms varying by 10ms", t, func() {
+		fault := LatencyFault{Delay: 100 * time.Millisecond, Jitter: 10 * time.Millisecond}
+
+		// sample returns the smallest and mean delay drawn from many executions.
+		sample := func() (time.Duration, time.Duration) {
+			min, total := time.Duration(-1), time.Duration(0)
+			for i := 0; i < 10000; i++ {
+				delay := fault.delay()
+				if min < 0 || delay < min {
+					min = delay
+				}
+				total += delay
+			}
+			return min, total / 10000
+		}
+
+		Convey("a uniform distribution adds up to the jitter", func() {
+			min, mean := sample()
+			So(min, ShouldBeGreaterThanOrEqualTo, 100*time.Millisecond)
+			So(mean, ShouldAlmostEqual, 105*time.Millisecond, time.Millisecond)
+		})
+
+		Convey("a normal distribution varies around the delay", func() {
+			fault.Distribution = LatencyNormal
+			min, mean := sample()
+			So(min, ShouldBeLessThan, 100*time.Millisecond)
+			So(mean, ShouldAlmostEqual, 100*time.Millisecond, time.Millisecond)
+		})
+
+		Convey("an exponential distribution adds the jitter on average", func() {
+			fault.Distribution = LatencyExponential
+			min, mean := sample()
+			So(min, ShouldBeGreaterThanOrEqualTo, 100*time.Millisecond)
+			So(mean, ShouldAlmostEqual, 110*time.Millisecond, time.Millisecond)
+		})
+
+		Convey("no jitter gives a fixed delay", func() {
+			fault.Jitter = 0
+			So(fault.delay(), ShouldEqual, 100*time.Millisecond)
+		})
+	})
+}
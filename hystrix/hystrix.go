@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
 )
 
 type runFunc func() error
@@ -34,8 +36,28 @@ type command struct {
 	circuit     *CircuitBreaker
 	run         runFuncC
 	fallback    fallbackFuncC
+	runStart    time.Time
 	runDuration time.Duration
 	events      []string
+
+	bulkheadKey      string
+	bulkheadAcquired bool
+	criticality      Criticality
+	tags             map[string]string
+	requestLog       *RequestLog
+	span             CommandSpan
+	faultInjected    bool
+	// shadowEvents are the decisions shadow mode kept from taking effect.
+	shadowEvents []string
+
+	// staleFallback marks the fallback as serving a last known good value.
+	staleFallback bool
+
+	circuitState CircuitState
+	fallbackRan  bool
+	fallbackErr  error
+	// reported is closed once the command's events have been reported.
+	reported chan struct{}
 }
 
 var (
@@ -45,6 +67,16 @@ var (
 	ErrCircuitOpen = CircuitError{Message: "circuit open"}
 	// ErrTimeout occurs when the provided function takes too long to execute.
 	ErrTimeout = CircuitError{Message: "timeout"}
+	// ErrKeyMaxConcurrency occurs when too many commands with the same bulkhead key are executed at the same time.
+	ErrKeyMaxConcurrency = CircuitError{Message: "max concurrency for bulkhead key"}
+	// ErrInsufficientDeadline occurs when the context deadline is too close for the command to be expected to finish.
+	ErrInsufficientDeadline = CircuitError{Message: "insufficient deadline"}
+	// ErrRateLimited occurs when a command is executed more often than its configured RequestsPerSecond.
+	ErrRateLimited = CircuitError{Message: "rate limited"}
+	// ErrSlowStart occurs when a circuit which has just closed turns the command away while traffic ramps back up.
+	ErrSlowStart = CircuitError{Message: "slow start"}
+	// ErrLoadShed occurs when the only tickets left in the pool are reserved for more critical executions.
+	ErrLoadShed = CircuitError{Message: "load shed"}
 )
 
 // Go runs your function while tracking the health of previous calls to it.
@@ -71,24 +103,37 @@ func Go(name string, run runFunc, fallback fallbackFunc) chan error {
 //
 // Define a fallback function if you want to define some code to execute during outages.
 func GoC(ctx context.Context, name string, run runFuncC, fallback fallbackFuncC) chan error {
-	cmd := &command{
+	return goC(ctx, name, newCommand(ctx, run, fallback))
+}
+
+func newCommand(ctx context.Context, run runFuncC, fallback fallbackFuncC) *command {
+	return &command{
 		run:      run,
 		fallback: fallback,
-		start:    time.Now(),
+		start:    clock.Default().Now(),
 		errChan:  make(chan error, 1),
 		finished: make(chan bool, 1),
+		reported: make(chan struct{}),
+
+		criticality: criticalityFromContext(ctx),
+		tags:        tagsFromContext(ctx),
+		requestLog:  RequestLogFromContext(ctx),
 	}
+}
 
+// goC executes cmd on the named circuit, returning the channel its error is sent on.
+func goC(ctx context.Context, name string, cmd *command) chan error {
 	// dont have methods with explicit params and returns
 	// let data come in and out naturally, like with any closure
 	// explicit error return to give place for us to kill switch the operation (fallback)
 
-	circuit, _, err := GetCircuit(name)
+	circuit, err := acquireCircuit(name)
 	if err != nil {
 		cmd.errChan <- err
 		return cmd.errChan
 	}
 	cmd.circuit = circuit
+	ctx = cmd.startTrace(ctx, name)
 	ticketCond := sync.NewCond(cmd)
 	ticketChecked := false
 	// When the caller extracts error from returned errChan, it's assumed that
@@ -100,67 +145,50 @@ func GoC(ctx context.Context, name string, run runFuncC, fallback fallbackFuncC)
 		for !ticketChecked {
 			ticketCond.Wait()
 		}
-		cmd.circuit.executorPool.Return(cmd.ticket)
+		cmd.releaseTicket()
 		cmd.Unlock()
 	}
 	// Shared by the following two goroutines. It ensures only the faster
 	// goroutine runs errWithFallback() and reportAllEvent().
 	returnOnce := &sync.Once{}
-	reportAllEvent := func() {
-		err := cmd.circuit.ReportEvent(cmd.events, cmd.start, cmd.runDuration)
-		if err != nil {
-			log.Printf(err.Error())
-		}
-	}
 
 	go func() {
 		defer func() { cmd.finished <- true }()
 
-		// Circuits get opened when recent executions have shown to have a high error rate.
-		// Rejecting new executions allows backends to recover, and the circuit will allow
-		// new traffic when it feels a healthly state has returned.
-		if !cmd.circuit.AllowRequest() {
-			cmd.Lock()
-			// It's safe for another goroutine to go ahead releasing a nil ticket.
-			ticketChecked = true
-			ticketCond.Signal()
-			cmd.Unlock()
-			returnOnce.Do(func() {
-				returnTicket()
-				cmd.errorWithFallback(ctx, ErrCircuitOpen)
-				reportAllEvent()
-			})
-			return
-		}
-
-		// As backends falter, requests take longer but don't always fail.
-		//
-		// When requests slow down but the incoming rate of requests stays the same, you have to
-		// run more at a time to keep up. By controlling concurrency during these situations, you can
-		// shed load which accumulates due to the increasing ratio of active commands to incoming requests.
 		cmd.Lock()
-		select {
-		case cmd.ticket = <-circuit.executorPool.Tickets:
-			ticketChecked = true
-			ticketCond.Signal()
-			cmd.Unlock()
-		default:
-			ticketChecked = true
-			ticketCond.Signal()
-			cmd.Unlock()
+		admitErr := cmd.admit(ctx)
+		// A rejected command holds no ticket, so it's safe for another goroutine
+		// to go ahead releasing a nil ticket.
+		ticketChecked = true
+		ticketCond.Signal()
+		cmd.Unlock()
+		if admitErr != nil {
 			returnOnce.Do(func() {
 				returnTicket()
-				cmd.errorWithFallback(ctx, ErrMaxConcurrency)
-				reportAllEvent()
+				cmd.errorWithFallback(ctx, admitErr)
+				cmd.reportAllEvent()
 			})
 			return
 		}
 
-		runStart := time.Now()
-		runErr := run(ctx)
+		runStart := circuit.clock.Now()
+		runCtx, endRun := cmd.startRunTrace(ctx)
+		runErr := cmd.injectFaults(runCtx)
+		if runErr == nil {
+			runErr = cmd.run(runCtx)
+		}
+		endRun(runErr)
 		returnOnce.Do(func() {
-			defer reportAllEvent()
-			cmd.runDuration = time.Since(runStart)
+			defer cmd.reportAllEvent()
+			cmd.runStart = runStart
+			cmd.runDuration = circuit.clock.Now().Sub(runStart)
+			// Shadow mode judges the timeout by how long run took, so that a
+			// run finishing just as the timer fires is judged only once.
+			if settings := getSettings(name); settings.ShadowMode && cmd.runDuration >= settings.Timeout {
+				cmd.Lock()
+				cmd.recordShadowEvent(ErrTimeout)
+				cmd.Unlock()
+			}
 			returnTicket()
 			if runErr != nil {
 				cmd.errorWithFallback(ctx, runErr)
@@ -171,26 +199,43 @@ func GoC(ctx context.Context, name string, run runFuncC, fallback fallbackFuncC)
 	}()
 
 	go func() {
-		timer := time.NewTimer(getSettings(name).Timeout)
+		timer := circuit.clock.NewTimer(getSettings(name).Timeout)
 		defer timer.Stop()
 
-		select {
-		case <-cmd.finished:
-			// returnOnce has been executed in another goroutine
-		case <-ctx.Done():
-			returnOnce.Do(func() {
-				returnTicket()
-				cmd.errorWithFallback(ctx, ctx.Err())
-				reportAllEvent()
-			})
-			return
-		case <-timer.C:
-			returnOnce.Do(func() {
-				returnTicket()
-				cmd.errorWithFallback(ctx, ErrTimeout)
-				reportAllEvent()
-			})
-			return
+		timeout := timer.C()
+		timedOut := false
+		for {
+			select {
+			case <-cmd.finished:
+				// returnOnce has been executed in another goroutine
+				return
+			case <-ctx.Done():
+				returnOnce.Do(func() {
+					if timedOut {
+						cmd.Lock()
+						cmd.recordShadowEvent(ErrTimeout)
+						cmd.Unlock()
+					}
+					returnTicket()
+					cmd.errorWithFallback(ctx, ctx.Err())
+					cmd.reportAllEvent()
+				})
+				return
+			case <-timeout:
+				if getSettings(name).ShadowMode {
+					// keep waiting for run, which is left to finish. Whether
+					// it timed out is recorded when it does.
+					timedOut = true
+					timeout = nil
+					continue
+				}
+				returnOnce.Do(func() {
+					returnTicket()
+					cmd.errorWithFallback(ctx, ErrTimeout)
+					cmd.reportAllEvent()
+				})
+				return
+			}
 		}
 	}()
 
@@ -214,10 +259,22 @@ func Do(name string, run runFunc, fallback fallbackFunc) error {
 
 // DoC runs your function in a synchronous manner, blocking until either your function succeeds
 // or an error is returned, including hystrix circuit errors
+//
+// Commands configured with IsolationSemaphore run on the calling goroutine.
 func DoC(ctx context.Context, name string, run runFuncC, fallback fallbackFuncC) error {
+	return doC(ctx, name, newCommand(ctx, run, fallback))
+}
+
+// doC executes cmd on the named circuit, blocking until either it succeeds or an error is returned.
+func doC(ctx context.Context, name string, cmd *command) error {
+	if getSettings(name).ExecutionIsolationStrategy == IsolationSemaphore {
+		return doSemaphoreC(ctx, name, cmd)
+	}
+
 	done := make(chan struct{}, 1)
 
-	r := func(ctx context.Context) error {
+	run := cmd.run
+	cmd.run = func(ctx context.Context) error {
 		err := run(ctx)
 		if err != nil {
 			return err
@@ -227,29 +284,183 @@ func DoC(ctx context.Context, name string, run runFuncC, fallback fallbackFuncC)
 		return nil
 	}
 
-	f := func(ctx context.Context, e error) error {
-		err := fallback(ctx, e)
-		if err != nil {
-			return err
-		}
+	if fallback := cmd.fallback; fallback != nil {
+		cmd.fallback = func(ctx context.Context, e error) error {
+			err := fallback(ctx, e)
+			if err != nil {
+				return err
+			}
 
-		done <- struct{}{}
-		return nil
+			done <- struct{}{}
+			return nil
+		}
 	}
 
-	var errChan chan error
-	if fallback == nil {
-		errChan = GoC(ctx, name, r, nil)
-	} else {
-		errChan = GoC(ctx, name, r, f)
-	}
+	errChan := goC(ctx, name, cmd)
 
+	var err error
 	select {
 	case <-done:
+	case err = <-errChan:
+	}
+
+	// Make sure the execution is in the request log by the time the caller looks.
+	if cmd.requestLog != nil && cmd.circuit != nil {
+		<-cmd.reported
+	}
+
+	return err
+}
+
+// admit runs the checks which decide whether the command may start, taking a
+// ticket from the pool if it can. Otherwise it returns the error to fall back with.
+//
+// In shadow mode the first check to fail is recorded instead, and the command is
+// admitted with whatever it managed to take.
+func (c *command) admit(ctx context.Context) (err error) {
+	// Errors injected by hystrixtest stand in for the outcome of the execution.
+	if err := c.circuit.takeInjectedError(); err != nil {
+		return err
+	}
+
+	shadow := getSettings(c.circuit.Name).ShadowMode
+	rejected := false
+	reject := func(err error) error {
+		if !shadow {
+			return err
+		}
+		if !rejected {
+			rejected = true
+			c.recordShadowEvent(err)
+		}
 		return nil
-	case err := <-errChan:
+	}
+
+	// A call which will certainly be cancelled before it completes only wastes
+	// a ticket and downstream capacity, so don't start it.
+	if !c.circuit.hasTimeFor(ctx) {
+		if err := reject(ErrInsufficientDeadline); err != nil {
+			return err
+		}
+	}
+
+	// Circuits get opened when recent executions have shown to have a high error rate.
+	// Rejecting new executions allows backends to recover, and the circuit will allow
+	// new traffic when it feels a healthly state has returned.
+	var test singleTest
+	c.circuitState, test = c.circuit.admissionState()
+	if c.circuitState == CircuitOpen {
+		if err := reject(ErrCircuitOpen); err != nil {
+			return err
+		}
+	}
+	if c.circuitState == CircuitHalfOpen {
+		defer func() {
+			if err != nil {
+				c.circuit.abandonSingleTest(test)
+			}
+		}()
+	}
+
+	// A backend which has only just recovered is likely to fall over again if
+	// it gets all of its traffic back at once.
+	if !c.circuit.allowDuringSlowStart() {
+		if err := reject(ErrSlowStart); err != nil {
+			return err
+		}
+	}
+
+	// A single caller, such as a noisy tenant, must not be able to take every
+	// ticket in the pool and starve everyone else calling the same dependency.
+	if key, ok := bulkheadKeyFromContext(ctx); ok {
+		c.bulkheadKey = key
+		if c.circuit.bulkhead.Acquire(key) {
+			c.bulkheadAcquired = true
+		} else if err := reject(ErrKeyMaxConcurrency); err != nil {
+			return err
+		}
+	}
+
+	// Concurrency limits don't protect downstreams which enforce a quota on the
+	// rate of requests, so those commands also need a token to proceed. It is
+	// taken last, so that executions turned away by the checks above don't use
+	// up the rate.
+	allowed := c.circuit.rateLimiter.Allow()
+	if !allowed {
+		if err := reject(ErrRateLimited); err != nil {
+			return err
+		}
+	}
+
+	// As backends falter, requests take longer but don't always fail.
+	//
+	// When requests slow down but the incoming rate of requests stays the same, you have to
+	// run more at a time to keep up. By controlling concurrency during these situations, you can
+	// shed load which accumulates due to the increasing ratio of active commands to incoming requests.
+	// Once the pool nears saturation, the remaining tickets are kept for more
+	// important work so that sheddable traffic is shed first.
+	ticket, err := c.circuit.executorPool.take(c.criticality)
+	if err != nil {
+		err = reject(err)
+		if err != nil && allowed {
+			c.circuit.rateLimiter.Refund()
+		}
 		return err
 	}
+	c.ticket = ticket
+	return nil
+}
+
+// releaseTicket returns everything admit took for the command.
+func (c *command) releaseTicket() {
+	c.circuit.executorPool.Return(c.ticket)
+	if c.bulkheadAcquired {
+		c.circuit.bulkhead.Release(c.bulkheadKey)
+	}
+}
+
+func (c *command) reportAllEvent() {
+	defer close(c.reported)
+	// the circuit may be evicted once the execution's metrics are in.
+	defer c.circuit.release()
+
+	c.Lock()
+	faultInjected := c.faultInjected
+	// Shadow events follow the events of what actually happened, so the
+	// first event still describes the outcome.
+	c.events = append(c.events, c.shadowEvents...)
+	c.Unlock()
+
+	execution := &commandExecution{
+		Types:         c.events,
+		Start:         c.start,
+		RunStart:      c.runStart,
+		RunDuration:   c.runDuration,
+		BulkheadKey:   c.bulkheadKey,
+		Criticality:   c.criticality,
+		Tags:          c.tags,
+		FaultInjected: faultInjected,
+	}
+	if err := c.circuit.report(execution); err != nil {
+		log.Log(LogLevelWarn, "failed to report execution",
+			LogField{"circuit", c.circuit.Name}, LogField{"events", c.events}, LogField{"error", err})
+	}
+	c.endTrace(execution.ConcurrencyInUse)
+
+	c.requestLog.add(RequestLogEntry{
+		Name:          c.circuit.Name,
+		Events:        append([]string(nil), c.events...),
+		RunDuration:   c.runDuration,
+		TotalDuration: c.since(c.start),
+	})
+}
+
+// since returns the time elapsed since t on the command's circuit clock.
+func (c *command) since(t time.Time) time.Duration {
+	if c.circuit == nil {
+		return clock.Default().Now().Sub(t)
+	}
+	return c.circuit.clock.Now().Sub(t)
 }
 
 func (c *command) reportEvent(eventType string) {
@@ -261,39 +472,61 @@ func (c *command) reportEvent(eventType string) {
 
 // errorWithFallback triggers the fallback while reporting the appropriate metric events.
 func (c *command) errorWithFallback(ctx context.Context, err error) {
-	eventType := "failure"
-	if err == ErrCircuitOpen {
-		eventType = "short-circuit"
-	} else if err == ErrMaxConcurrency {
-		eventType = "rejected"
-	} else if err == ErrTimeout {
-		eventType = "timeout"
-	} else if err == context.Canceled {
-		eventType = "context_canceled"
-	} else if err == context.DeadlineExceeded {
-		eventType = "context_deadline_exceeded"
-	}
-
-	c.reportEvent(eventType)
+	c.reportEvent(eventTypeFor(err))
 	fallbackErr := c.tryFallback(ctx, err)
 	if fallbackErr != nil {
 		c.errChan <- fallbackErr
 	}
 }
 
+// eventTypeFor returns the event reported for an execution which failed with err.
+func eventTypeFor(err error) string {
+	switch err {
+	case ErrCircuitOpen:
+		return "short-circuit"
+	case ErrMaxConcurrency:
+		return "rejected"
+	case ErrKeyMaxConcurrency:
+		return "key-rejected"
+	case ErrTimeout:
+		return "timeout"
+	case ErrRateLimited:
+		return "rate-limited"
+	case ErrInsufficientDeadline:
+		return "insufficient-deadline"
+	case ErrSlowStart:
+		return "slow-start"
+	case ErrLoadShed:
+		return "shed"
+	case context.Canceled:
+		return "context_canceled"
+	case context.DeadlineExceeded:
+		return "context_deadline_exceeded"
+	}
+	return "failure"
+}
+
 func (c *command) tryFallback(ctx context.Context, err error) error {
 	if c.fallback == nil {
 		// If we don't have a fallback return the original error.
 		return err
 	}
 
-	fallbackErr := c.fallback(ctx, err)
+	fallbackCtx, endFallback := c.startFallbackTrace(ctx)
+	fallbackErr := c.fallback(fallbackCtx, err)
+	endFallback(fallbackErr)
+	c.fallbackRan = true
+	c.fallbackErr = fallbackErr
 	if fallbackErr != nil {
 		c.reportEvent("fallback-failure")
 		return fmt.Errorf("fallback failed with '%v'. run error was '%v'", fallbackErr, err)
 	}
 
-	c.reportEvent("fallback-success")
+	if c.staleFallback {
+		c.reportEvent("fallback-stale")
+	} else {
+		c.reportEvent("fallback-success")
+	}
 
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 
 	"testing/quick"
 
+	"github.com/afex/hystrix-go/hystrix/clock"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -217,6 +218,9 @@ func TestFailedFallback(t *testing.T) {
 
 func TestCloseCircuitAfterSuccess(t *testing.T) {
 	Convey("when a circuit is open", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
 		defer Flush()
 		cb, _, err := GetCircuit("")
 		So(err, ShouldEqual, nil)
@@ -232,7 +236,7 @@ func TestCloseCircuitAfterSuccess(t *testing.T) {
 		})
 
 		Convey("and a successful command is run after the sleep window", func() {
-			time.Sleep(6 * time.Second)
+			fake.Advance(6 * time.Second)
 
 			done := make(chan bool, 1)
 			GoC(context.Background(), "", func(ctx context.Context) error {
@@ -0,0 +1,154 @@
+// Package hystrixtest helps test code which runs hystrix commands, by putting
+// circuits into known states and recording the metrics they emit, without
+// waiting for real failures or timeouts.
+package hystrixtest
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/afex/hystrix-go/hystrix/internal/testhooks"
+	"github.com/afex/hystrix-go/hystrix/metric_collector"
+)
+
+// Isolate gives the test its own empty set of circuits and command settings,
+// with no faults, tracer or eviction and the default logger, restoring the
+// previous ones when the test finishes. Unlike hystrix.Flush, it
+// leaves circuits in use by other tests untouched. Tests which call Isolate
+// must not run in parallel with each other.
+func Isolate(t testing.TB) {
+	t.Helper()
+
+	restore := testhooks.Isolate()
+	t.Cleanup(restore)
+}
+
+// SetState forces the named circuit into the given state. A half open circuit
+// allows a single execution through to test whether it should close. A closed
+// circuit admits all traffic at once; use StartSlowStart to test its ramp.
+func SetState(t testing.TB, name string, state hystrix.CircuitState) {
+	t.Helper()
+
+	if err := testhooks.SetCircuitState(name, string(state)); err != nil {
+		t.Fatalf("hystrixtest: setting circuit %v to %v: %v", name, state, err)
+	}
+}
+
+// StartSlowStart closes the named circuit as if it had just recovered, so that
+// it ramps traffic back up over its SlowStartWindow.
+func StartSlowStart(t testing.TB, name string) {
+	t.Helper()
+
+	if err := testhooks.StartSlowStart(name); err != nil {
+		t.Fatalf("hystrixtest: starting the slow start of circuit %v: %v", name, err)
+	}
+}
+
+// TimeoutNext makes the next n executions of the named circuit time out
+// without running, so that their fallbacks run with hystrix.ErrTimeout.
+func TimeoutNext(t testing.TB, name string, n int) {
+	t.Helper()
+	injectNext(t, name, n, hystrix.ErrTimeout)
+}
+
+// RejectNext makes the next n executions of the named circuit be rejected
+// without running, so that their fallbacks run with hystrix.ErrMaxConcurrency.
+func RejectNext(t testing.TB, name string, n int) {
+	t.Helper()
+	injectNext(t, name, n, hystrix.ErrMaxConcurrency)
+}
+
+func injectNext(t testing.TB, name string, n int, err error) {
+	t.Helper()
+
+	if injectErr := testhooks.InjectErrors(name, n, err); injectErr != nil {
+		t.Fatalf("hystrixtest: injecting errors into circuit %v: %v", name, injectErr)
+	}
+}
+
+// MetricRecorder is a MetricCollector which keeps every MetricResult it receives.
+type MetricRecorder struct {
+	mutex   *sync.Mutex
+	results []metricCollector.MetricResult
+}
+
+// RecordMetrics returns a MetricRecorder receiving the metrics of the named circuit.
+func RecordMetrics(t testing.TB, name string) *MetricRecorder {
+	t.Helper()
+
+	r := &MetricRecorder{mutex: &sync.Mutex{}}
+	if err := testhooks.AddCollector(name, r); err != nil {
+		t.Fatalf("hystrixtest: recording metrics of circuit %v: %v", name, err)
+	}
+	return r
+}
+
+// Update records a result.
+func (r *MetricRecorder) Update(result metricCollector.MetricResult) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.results = append(r.results, result)
+}
+
+// Reset forgets every result recorded so far.
+func (r *MetricRecorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.results = nil
+}
+
+// Results returns the results recorded so far, in the order they were received.
+func (r *MetricRecorder) Results() []metricCollector.MetricResult {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]metricCollector.MetricResult(nil), r.results...)
+}
+
+// Wait blocks until at least n results have been recorded, failing the test if
+// they do not arrive within a second. Metrics are delivered asynchronously, so
+// call it before making assertions about executions which have just finished.
+func (r *MetricRecorder) Wait(t testing.TB, n int) []metricCollector.MetricResult {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		results := r.Results()
+		if len(results) >= n {
+			return results
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("hystrixtest: waited for %v metric results, got %v", n, len(results))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Total sums the counts and durations of every result recorded so far.
+func (r *MetricRecorder) Total() metricCollector.MetricResult {
+	var total metricCollector.MetricResult
+	for _, result := range r.Results() {
+		total = total.Add(result)
+	}
+	return total
+}
+
+// AssertCounts fails the test unless the counts summed by Total equal those of
+// want. Durations, concurrency and the descriptive fields are not compared.
+func (r *MetricRecorder) AssertCounts(t testing.TB, want metricCollector.MetricResult) {
+	t.Helper()
+
+	got := r.Total()
+	got.TotalDuration, got.RunDuration, got.MonitorLag = want.TotalDuration, want.RunDuration, want.MonitorLag
+	got.ConcurrencyInUse, got.Aggregated, got.RunStart = want.ConcurrencyInUse, want.Aggregated, want.RunStart
+	got.BulkheadKey, got.Criticality, got.Tags = want.BulkheadKey, want.Criticality, want.Tags
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("hystrixtest: metric counts were %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+package hystrixtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/afex/hystrix-go/hystrix/metric_collector"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func fallbackWith(errs *[]error) func(context.Context, error) error {
+	return func(ctx context.Context, err error) error {
+		*errs = append(*errs, err)
+		return nil
+	}
+}
+
+func TestSetState(t *testing.T) {
+	Convey("with an isolated circuit", t, func() {
+		Isolate(t)
+		hystrix.ConfigureCommand("dependency", hystrix.CommandConfig{SleepWindow: 60000})
+
+		run := func(ctx context.Context) error { return nil }
+
+		Convey("an open circuit short-circuits executions", func() {
+			SetState(t, "dependency", hystrix.CircuitOpen)
+
+			err := hystrix.DoC(context.Background(), "dependency", run, nil)
+			So(err, ShouldResemble, hystrix.ErrCircuitOpen)
+		})
+
+		Convey("a half open circuit allows a single test", func() {
+			SetState(t, "dependency", hystrix.CircuitHalfOpen)
+
+			result, err := hystrix.DoResultC(context.Background(), "dependency", func(ctx context.Context) error {
+				return fmt.Errorf("still broken")
+			}, nil)
+			So(err, ShouldNotBeNil)
+			So(result.CircuitState, ShouldEqual, hystrix.CircuitHalfOpen)
+
+			err = hystrix.DoC(context.Background(), "dependency", run, nil)
+			So(err, ShouldResemble, hystrix.ErrCircuitOpen)
+		})
+
+		Convey("a closed circuit runs executions again", func() {
+			SetState(t, "dependency", hystrix.CircuitOpen)
+			SetState(t, "dependency", hystrix.CircuitClosed)
+
+			So(hystrix.DoC(context.Background(), "dependency", run, nil), ShouldBeNil)
+		})
+
+		Convey("a closed circuit admits all traffic, unless its slow start is started", func() {
+			hystrix.ConfigureCommand("dependency", hystrix.CommandConfig{SlowStartWindow: 60000})
+			cb, _, _ := hystrix.GetCircuit("dependency")
+
+			SetState(t, "dependency", hystrix.CircuitOpen)
+			SetState(t, "dependency", hystrix.CircuitClosed)
+			So(cb.SlowStartPercent(), ShouldEqual, 100)
+
+			StartSlowStart(t, "dependency")
+			So(cb.IsOpen(), ShouldBeFalse)
+			So(cb.SlowStartPercent(), ShouldBeLessThan, 100)
+		})
+	})
+}
+
+func TestInjection(t *testing.T) {
+	Convey("with an isolated circuit whose metrics are recorded", t, func() {
+		Isolate(t)
+		recorder := RecordMetrics(t, "dependency")
+
+		ran := 0
+		run := func(ctx context.Context) error {
+			ran++
+			return nil
+		}
+		var errs []error
+
+		Convey("the next executions time out without running", func() {
+			TimeoutNext(t, "dependency", 2)
+
+			for i := 0; i < 3; i++ {
+				So(hystrix.DoC(context.Background(), "dependency", run, fallbackWith(&errs)), ShouldBeNil)
+			}
+			So(ran, ShouldEqual, 1)
+			So(errs, ShouldResemble, []error{hystrix.ErrTimeout, hystrix.ErrTimeout})
+
+			recorder.Wait(t, 3)
+			recorder.AssertCounts(t, metricCollector.MetricResult{
+				Attempts:          3,
+				Errors:            2,
+				Successes:         1,
+				Timeouts:          2,
+				FallbackSuccesses: 2,
+			})
+		})
+
+		Convey("the next executions are rejected without running", func() {
+			RejectNext(t, "dependency", 1)
+
+			So(hystrix.DoC(context.Background(), "dependency", run, fallbackWith(&errs)), ShouldBeNil)
+			So(ran, ShouldEqual, 0)
+			So(errs, ShouldResemble, []error{hystrix.ErrMaxConcurrency})
+
+			results := recorder.Wait(t, 1)
+			So(results[0].Rejects, ShouldEqual, 1)
+		})
+	})
+}
+
+func TestIsolate(t *testing.T) {
+	Convey("with a circuit configured outside of an isolated test", t, func() {
+		hystrix.ConfigureCommand("outside", hystrix.CommandConfig{Timeout: 1234})
+		defer hystrix.Flush()
+
+		Convey("it is hidden during the test and restored afterwards", func() {
+			t.Run("isolated", func(t *testing.T) {
+				Isolate(t)
+				if timeout := hystrix.GetCircuitSettings()["outside"]; timeout != nil {
+					t.Errorf("settings leaked into the isolated test: %+v", timeout)
+				}
+			})
+
+			So(hystrix.GetCircuitSettings()["outside"].Timeout.Milliseconds(), ShouldEqual, 1234)
+		})
+
+		Convey("its faults are hidden during the test and restored afterwards", func() {
+			hystrix.ConfigureFaults("outside", hystrix.FaultConfig{Error: hystrix.ErrorFault{FaultScope: hystrix.FaultScope{Percent: 100}}})
+			defer hystrix.ClearFaults("outside")
+			run := func(ctx context.Context) error { return nil }
+
+			t.Run("isolated", func(t *testing.T) {
+				Isolate(t)
+				if err := hystrix.DoC(context.Background(), "outside", run, nil); err != nil {
+					t.Errorf("faults leaked into the isolated test: %v", err)
+				}
+			})
+
+			So(hystrix.DoC(context.Background(), "outside", run, nil), ShouldResemble, hystrix.ErrFaultInjected)
+		})
+	})
+}
@@ -0,0 +1,21 @@
+// Package testhooks gives hystrixtest access to the internals of the hystrix
+// package without making them part of its API. The hystrix package sets each
+// hook when it is initialized.
+package testhooks
+
+import "github.com/afex/hystrix-go/hystrix/metric_collector"
+
+var (
+	// SetCircuitState forces the named circuit into "OPEN", "HALF_OPEN" or "CLOSED".
+	SetCircuitState func(name string, state string) error
+	// StartSlowStart closes the named circuit as if it had just recovered, starting its slow start.
+	StartSlowStart func(name string) error
+	// InjectErrors makes the next n executions of the named circuit fail with err instead of running.
+	InjectErrors func(name string, n int, err error) error
+	// AddCollector sends the named circuit's metrics to collector as well.
+	AddCollector func(name string, collector metricCollector.MetricCollector) error
+	// Isolate swaps the global settings, circuits, faults, tracer, logger and
+	// eviction config for empty ones, returning a function which closes any
+	// circuits created since and restores them.
+	Isolate func() (restore func())
+)
@@ -1,11 +1,83 @@
 package hystrix
 
-type logger interface {
+import (
+	"bytes"
+	"fmt"
+)
+
+// LogLevel is the severity of a log event.
+type LogLevel int
+
+const (
+	// LogLevelDebug events are only useful when investigating hystrix itself.
+	LogLevelDebug LogLevel = iota
+	// LogLevelInfo events describe normal changes, such as a circuit closing.
+	LogLevelInfo
+	// LogLevelWarn events describe degraded behavior, such as a circuit opening.
+	LogLevelWarn
+	// LogLevelError events describe failures within hystrix.
+	LogLevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(l))
+}
+
+// LogField is a key/value pair describing a log event, such as the circuit it is about.
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger receives structured log events from the hystrix package.
+// Use SetStructuredLogger to configure one.
+type Logger interface {
+	Log(level LogLevel, msg string, fields ...LogField)
+}
+
+// PrintfLogger is the interface of loggers given to SetLogger, such as the
+// standard library's *log.Logger.
+type PrintfLogger interface {
 	Printf(format string, items ...interface{})
 }
 
+type printfLogger struct {
+	logger PrintfLogger
+}
+
+// NewPrintfLogger adapts a PrintfLogger into a Logger. Each event is printed on
+// one line as its message followed by its fields in key=value form. Events of
+// every level are printed.
+func NewPrintfLogger(l PrintfLogger) Logger {
+	return printfLogger{logger: l}
+}
+
+func (l printfLogger) Log(level LogLevel, msg string, fields ...LogField) {
+	var b bytes.Buffer
+	b.WriteString("hystrix-go: ")
+	b.WriteString(msg)
+	for _, field := range fields {
+		fmt.Fprintf(&b, " %v=%v", field.Key, field.Value)
+	}
+
+	l.logger.Printf("%s", b.String())
+}
+
 // NoopLogger does not log anything.
 type NoopLogger struct{}
 
 // Printf does nothing.
 func (l NoopLogger) Printf(format string, items ...interface{}) {}
+
+// Log does nothing.
+func (l NoopLogger) Log(level LogLevel, msg string, fields ...LogField) {}
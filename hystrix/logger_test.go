@@ -0,0 +1,82 @@
+package hystrix
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type recordedLog struct {
+	level  LogLevel
+	msg    string
+	fields []LogField
+}
+
+type recordingLogger struct {
+	mutex *sync.Mutex
+	logs  []recordedLog
+}
+
+func (l *recordingLogger) Log(level LogLevel, msg string, fields ...LogField) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.logs = append(l.logs, recordedLog{level, msg, fields})
+}
+
+type printfRecorder struct {
+	lines []string
+}
+
+func (p *printfRecorder) Printf(format string, items ...interface{}) {
+	p.lines = append(p.lines, fmt.Sprintf(format, items...))
+}
+
+func TestPrintfLogger(t *testing.T) {
+	Convey("with a Printf logger", t, func() {
+		recorder := &printfRecorder{}
+		logger := NewPrintfLogger(recorder)
+
+		Convey("events are printed with their fields", func() {
+			logger.Log(LogLevelWarn, "opening circuit", LogField{"circuit", "foo"}, LogField{"error_percent", 60})
+			So(recorder.lines, ShouldResemble, []string{"hystrix-go: opening circuit circuit=foo error_percent=60"})
+		})
+
+		Convey("messages are not treated as format strings", func() {
+			logger.Log(LogLevelWarn, "100% broken")
+			So(recorder.lines, ShouldResemble, []string{"hystrix-go: 100% broken"})
+		})
+	})
+}
+
+func TestStructuredLogger(t *testing.T) {
+	Convey("with a structured logger", t, func() {
+		recorder := &recordingLogger{mutex: &sync.Mutex{}}
+		SetStructuredLogger(recorder)
+		defer SetStructuredLogger(DefaultLogger)
+		defer Flush()
+
+		Convey("opening and closing a circuit emit events describing it", func() {
+			cb, _, _ := GetCircuit("foo")
+			cb.setOpen()
+			cb.setClose()
+
+			recorder.mutex.Lock()
+			defer recorder.mutex.Unlock()
+			So(recorder.logs, ShouldResemble, []recordedLog{
+				{LogLevelWarn, "opening circuit", []LogField{{"circuit", "foo"}, {"state", CircuitOpen}, {"error_percent", 0}}},
+				{LogLevelInfo, "closing circuit", []LogField{{"circuit", "foo"}, {"state", CircuitClosed}}},
+			})
+		})
+	})
+
+	Convey("when a Printf logger which is also structured is set", t, func() {
+		SetLogger(NoopLogger{})
+		defer SetStructuredLogger(DefaultLogger)
+
+		Convey("it is used directly", func() {
+			So(log, ShouldResemble, NoopLogger{})
+		})
+	})
+}
@@ -2,7 +2,9 @@ package metricCollector
 
 import (
 	"sync"
+	"time"
 
+	"github.com/afex/hystrix-go/hystrix/clock"
 	"github.com/afex/hystrix-go/hystrix/rolling"
 )
 
@@ -14,6 +16,12 @@ import (
 // Metric Collectors do not need Mutexes as they are updated by circuits within a locked context.
 type DefaultMetricCollector struct {
 	mutex *sync.RWMutex
+	// clock is the default clock when the collector was created, which its
+	// metrics keep using when they are reset.
+	clock clock.Clock
+
+	numberWindow rolling.Window
+	timingWindow rolling.Window
 
 	numRequests *rolling.Number
 	errors      *rolling.Number
@@ -23,18 +31,40 @@ type DefaultMetricCollector struct {
 	rejects                 *rolling.Number
 	shortCircuits           *rolling.Number
 	timeouts                *rolling.Number
+	rateLimited             *rolling.Number
+	insufficientDeadline    *rolling.Number
+	slowStartRejects        *rolling.Number
+	shed                    *rolling.Number
 	contextCanceled         *rolling.Number
 	contextDeadlineExceeded *rolling.Number
 
 	fallbackSuccesses *rolling.Number
 	fallbackFailures  *rolling.Number
-	totalDuration     *rolling.Timing
-	runDuration       *rolling.Timing
+	fallbackStale     *rolling.Number
+	faultInjected     *rolling.Number
+
+	shadowShortCircuits *rolling.Number
+	shadowRejects       *rolling.Number
+	shadowTimeouts      *rolling.Number
+
+	droppedUpdates *rolling.Number
+	monitorLag     *rolling.Timing
+
+	totalDuration *rolling.Timing
+	runDuration   *rolling.Timing
+
+	// rejectsByKey counts rejections per bulkhead key. Keys are dropped once
+	// no rejections are left in their window, so memory stays bounded by the
+	// keys being rejected rather than every key ever seen.
+	keyMutex     *sync.Mutex
+	rejectsByKey map[string]*rolling.Number
 }
 
 func newDefaultMetricCollector(name string) MetricCollector {
 	m := &DefaultMetricCollector{}
 	m.mutex = &sync.RWMutex{}
+	m.keyMutex = &sync.Mutex{}
+	m.clock = clock.Default()
 	m.Reset()
 	return m
 }
@@ -88,6 +118,34 @@ func (d *DefaultMetricCollector) Timeouts() *rolling.Number {
 	return d.timeouts
 }
 
+// RateLimited returns the rolling number of executions rejected by the rate limiter
+func (d *DefaultMetricCollector) RateLimited() *rolling.Number {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.rateLimited
+}
+
+// InsufficientDeadline returns the rolling number of executions rejected because their deadline was too close
+func (d *DefaultMetricCollector) InsufficientDeadline() *rolling.Number {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.insufficientDeadline
+}
+
+// SlowStartRejects returns the rolling number of executions turned away while the circuit ramped up after closing
+func (d *DefaultMetricCollector) SlowStartRejects() *rolling.Number {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.slowStartRejects
+}
+
+// Shed returns the rolling number of executions turned away to keep the pool's reserve for more critical ones
+func (d *DefaultMetricCollector) Shed() *rolling.Number {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.shed
+}
+
 // FallbackSuccesses returns the rolling number of fallback successes
 func (d *DefaultMetricCollector) FallbackSuccesses() *rolling.Number {
 	d.mutex.RLock()
@@ -95,6 +153,41 @@ func (d *DefaultMetricCollector) FallbackSuccesses() *rolling.Number {
 	return d.fallbackSuccesses
 }
 
+// FallbackStale returns the rolling number of fallbacks served from a stale value
+func (d *DefaultMetricCollector) FallbackStale() *rolling.Number {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.fallbackStale
+}
+
+// FaultInjected returns the rolling number of executions which had faults injected
+func (d *DefaultMetricCollector) FaultInjected() *rolling.Number {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.faultInjected
+}
+
+// ShadowShortCircuits returns the rolling number of executions shadow mode ran although the circuit was open
+func (d *DefaultMetricCollector) ShadowShortCircuits() *rolling.Number {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.shadowShortCircuits
+}
+
+// ShadowRejects returns the rolling number of executions shadow mode ran although they would have been rejected
+func (d *DefaultMetricCollector) ShadowRejects() *rolling.Number {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.shadowRejects
+}
+
+// ShadowTimeouts returns the rolling number of executions shadow mode let run past their timeout
+func (d *DefaultMetricCollector) ShadowTimeouts() *rolling.Number {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.shadowTimeouts
+}
+
 func (d *DefaultMetricCollector) ContextCanceled() *rolling.Number {
 	d.mutex.RLock()
 	defer d.mutex.RUnlock()
@@ -128,6 +221,60 @@ func (d *DefaultMetricCollector) RunDuration() *rolling.Timing {
 	return d.runDuration
 }
 
+// DroppedUpdates returns the rolling number of executions left out of the metrics because the buffer was full
+func (d *DefaultMetricCollector) DroppedUpdates() *rolling.Number {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.droppedUpdates
+}
+
+// MonitorLag returns the rolling time executions waited in the metrics buffer
+func (d *DefaultMetricCollector) MonitorLag() *rolling.Timing {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.monitorLag
+}
+
+// RejectsByKey returns the number of rejections in the window for each
+// bulkhead key which has any.
+func (d *DefaultMetricCollector) RejectsByKey(now time.Time) map[string]float64 {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	d.keyMutex.Lock()
+	defer d.keyMutex.Unlock()
+
+	d.removeIdleKeys(now)
+	rejects := make(map[string]float64, len(d.rejectsByKey))
+	for key, n := range d.rejectsByKey {
+		rejects[key] = n.Sum(now)
+	}
+	return rejects
+}
+
+// addKeyedRejects counts rejections for key. The caller must hold mutex.
+func (d *DefaultMetricCollector) addKeyedRejects(key string, rejects float64) {
+	d.keyMutex.Lock()
+	defer d.keyMutex.Unlock()
+
+	n, ok := d.rejectsByKey[key]
+	if !ok {
+		d.removeIdleKeys(d.clock.Now())
+		n = d.newNumber()
+		d.rejectsByKey[key] = n
+	}
+	n.Increment(rejects)
+}
+
+// removeIdleKeys drops the keys without rejections in the window. The caller
+// must hold keyMutex.
+func (d *DefaultMetricCollector) removeIdleKeys(now time.Time) {
+	for key, n := range d.rejectsByKey {
+		if n.Sum(now) == 0 {
+			delete(d.rejectsByKey, key)
+		}
+	}
+}
+
 func (d *DefaultMetricCollector) Update(r MetricResult) {
 	d.mutex.RLock()
 	defer d.mutex.RUnlock()
@@ -139,13 +286,32 @@ func (d *DefaultMetricCollector) Update(r MetricResult) {
 	d.rejects.Increment(r.Rejects)
 	d.shortCircuits.Increment(r.ShortCircuits)
 	d.timeouts.Increment(r.Timeouts)
+	d.rateLimited.Increment(r.RateLimited)
+	d.insufficientDeadline.Increment(r.InsufficientDeadline)
+	d.slowStartRejects.Increment(r.SlowStartRejects)
+	d.shed.Increment(r.Shed)
 	d.fallbackSuccesses.Increment(r.FallbackSuccesses)
 	d.fallbackFailures.Increment(r.FallbackFailures)
+	d.fallbackStale.Increment(r.FallbackStale)
+	d.faultInjected.Increment(r.FaultInjected)
+	d.shadowShortCircuits.Increment(r.ShadowShortCircuits)
+	d.shadowRejects.Increment(r.ShadowRejects)
+	d.shadowTimeouts.Increment(r.ShadowTimeouts)
 	d.contextCanceled.Increment(r.ContextCanceled)
 	d.contextDeadlineExceeded.Increment(r.ContextDeadlineExceeded)
 
-	d.totalDuration.Add(r.TotalDuration)
-	d.runDuration.Add(r.RunDuration)
+	d.droppedUpdates.Increment(r.DroppedUpdates)
+	if r.Rejects > 0 && r.BulkheadKey != "" {
+		d.addKeyedRejects(r.BulkheadKey, r.Rejects)
+	}
+
+	if !r.Aggregated {
+		d.totalDuration.Add(r.TotalDuration)
+		d.monitorLag.Add(r.MonitorLag)
+	}
+	if r.Ran() {
+		d.runDuration.Add(r.RunDuration)
+	}
 }
 
 // Reset resets all metrics in this collector to 0.
@@ -153,17 +319,48 @@ func (d *DefaultMetricCollector) Reset() {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
-	d.numRequests = rolling.NewNumber()
-	d.errors = rolling.NewNumber()
-	d.successes = rolling.NewNumber()
-	d.rejects = rolling.NewNumber()
-	d.shortCircuits = rolling.NewNumber()
-	d.failures = rolling.NewNumber()
-	d.timeouts = rolling.NewNumber()
-	d.fallbackSuccesses = rolling.NewNumber()
-	d.fallbackFailures = rolling.NewNumber()
-	d.contextCanceled = rolling.NewNumber()
-	d.contextDeadlineExceeded = rolling.NewNumber()
-	d.totalDuration = rolling.NewTiming()
-	d.runDuration = rolling.NewTiming()
+	d.numRequests = d.newNumber()
+	d.errors = d.newNumber()
+	d.successes = d.newNumber()
+	d.rejects = d.newNumber()
+	d.shortCircuits = d.newNumber()
+	d.failures = d.newNumber()
+	d.timeouts = d.newNumber()
+	d.rateLimited = d.newNumber()
+	d.insufficientDeadline = d.newNumber()
+	d.slowStartRejects = d.newNumber()
+	d.shed = d.newNumber()
+	d.fallbackSuccesses = d.newNumber()
+	d.fallbackFailures = d.newNumber()
+	d.fallbackStale = d.newNumber()
+	d.faultInjected = d.newNumber()
+	d.shadowShortCircuits = d.newNumber()
+	d.shadowRejects = d.newNumber()
+	d.shadowTimeouts = d.newNumber()
+	d.contextCanceled = d.newNumber()
+	d.contextDeadlineExceeded = d.newNumber()
+	d.droppedUpdates = d.newNumber()
+	d.totalDuration = d.newTiming()
+	d.runDuration = d.newTiming()
+	d.monitorLag = d.newTiming()
+	d.rejectsByKey = make(map[string]*rolling.Number)
+}
+
+// SetRollingWindows sets the windows of the collector's rolling numbers and
+// timings, resetting all of its metrics to 0.
+func (d *DefaultMetricCollector) SetRollingWindows(number, timing rolling.Window) {
+	d.mutex.Lock()
+	d.numberWindow = number
+	d.timingWindow = timing
+	d.mutex.Unlock()
+
+	d.Reset()
+}
+
+func (d *DefaultMetricCollector) newNumber() *rolling.Number {
+	return rolling.NewNumberWithWindow(d.numberWindow, d.clock)
+}
+
+func (d *DefaultMetricCollector) newTiming() *rolling.Timing {
+	return rolling.NewTimingWithWindow(d.timingWindow, d.clock)
 }
@@ -47,18 +47,90 @@ type MetricResult struct {
 	Rejects                 float64
 	ShortCircuits           float64
 	Timeouts                float64
+	RateLimited             float64
+	InsufficientDeadline    float64
+	SlowStartRejects        float64
+	Shed                    float64
 	FallbackSuccesses       float64
 	FallbackFailures        float64
+	FallbackStale           float64
+	FaultInjected           float64
+	ShadowShortCircuits     float64
+	ShadowRejects           float64
+	ShadowTimeouts          float64
 	ContextCanceled         float64
 	ContextDeadlineExceeded float64
 	TotalDuration           time.Duration
 	RunDuration             time.Duration
 	ConcurrencyInUse        float64
+	// DroppedUpdates is how many executions were left out of the circuit's
+	// metrics because its metrics buffer was full. They are reported in an
+	// Aggregated result as soon as they are dropped.
+	DroppedUpdates float64
+	// MonitorLag is how long the result waited in the metrics buffer before
+	// being processed.
+	MonitorLag time.Duration
+	// RunStart is when run was called, or zero if the execution never ran or
+	// run did not finish in time. RunDuration only measures run when it is set.
+	RunStart time.Time
+	// Aggregated is set when the result sums the counts of executions which
+	// didn't fit in the metrics buffer, rather than describing one execution.
+	// It has no durations or concurrency, so collectors must not record those
+	// as samples.
+	Aggregated bool
+	// BulkheadKey is the key the execution was limited by, if any, so that
+	// rejections can be attributed to the caller which caused them.
+	BulkheadKey string
+	// Criticality is the priority the execution was admitted or rejected with,
+	// such as "CRITICAL", "DEFAULT" or "SHEDDABLE".
+	Criticality string
+	// Tags are the tags the execution's context was given with hystrix.WithTags.
+	// The map is shared between collectors and must not be modified.
+	Tags map[string]string
+}
+
+// Add returns r with the counts and durations of other added to its own. The
+// remaining fields are those of r.
+func (r MetricResult) Add(other MetricResult) MetricResult {
+	r.Attempts += other.Attempts
+	r.Errors += other.Errors
+	r.Successes += other.Successes
+	r.Failures += other.Failures
+	r.Rejects += other.Rejects
+	r.ShortCircuits += other.ShortCircuits
+	r.Timeouts += other.Timeouts
+	r.RateLimited += other.RateLimited
+	r.InsufficientDeadline += other.InsufficientDeadline
+	r.SlowStartRejects += other.SlowStartRejects
+	r.Shed += other.Shed
+	r.FallbackSuccesses += other.FallbackSuccesses
+	r.FallbackFailures += other.FallbackFailures
+	r.FallbackStale += other.FallbackStale
+	r.FaultInjected += other.FaultInjected
+	r.ShadowShortCircuits += other.ShadowShortCircuits
+	r.ShadowRejects += other.ShadowRejects
+	r.ShadowTimeouts += other.ShadowTimeouts
+	r.ContextCanceled += other.ContextCanceled
+	r.ContextDeadlineExceeded += other.ContextDeadlineExceeded
+	r.DroppedUpdates += other.DroppedUpdates
+	r.TotalDuration += other.TotalDuration
+	r.RunDuration += other.RunDuration
+	r.MonitorLag += other.MonitorLag
+	return r
+}
+
+// Ran reports whether the result describes an execution whose run was called
+// and finished, so that RunDuration is a measurement of it. Executions which
+// were rejected, short-circuited or timed out have no run duration to record.
+func (r MetricResult) Ran() bool {
+	return r.Attempts > 0 && !r.RunStart.IsZero()
 }
 
 // MetricCollector represents the contract that all collectors must fulfill to gather circuit statistics.
 // Implementations of this interface do not have to maintain locking around thier data stores so long as
 // they are not modified outside of the hystrix context.
+//
+// Collectors which also implement io.Closer are closed when their circuit is removed.
 type MetricCollector interface {
 	// Update accepts a set of metrics from a command execution for remote instrumentation
 	Update(MetricResult)
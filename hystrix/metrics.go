@@ -1,18 +1,28 @@
 package hystrix
 
 import (
+	"io"
 	"sync"
 	"time"
 
+	"github.com/afex/hystrix-go/hystrix/clock"
 	"github.com/afex/hystrix-go/hystrix/metric_collector"
 	"github.com/afex/hystrix-go/hystrix/rolling"
 )
 
 type commandExecution struct {
-	Types            []string      `json:"types"`
-	Start            time.Time     `json:"start_time"`
-	RunDuration      time.Duration `json:"run_duration"`
-	ConcurrencyInUse float64       `json:"concurrency_inuse"`
+	Types            []string          `json:"types"`
+	Start            time.Time         `json:"start_time"`
+	RunStart         time.Time         `json:"run_start_time"`
+	RunDuration      time.Duration     `json:"run_duration"`
+	ConcurrencyInUse float64           `json:"concurrency_inuse"`
+	BulkheadKey      string            `json:"bulkhead_key,omitempty"`
+	Criticality      Criticality       `json:"criticality,omitempty"`
+	Tags             map[string]string `json:"tags,omitempty"`
+	FaultInjected    bool              `json:"fault_injected,omitempty"`
+
+	// queued is when the execution was sent to the metrics buffer.
+	queued time.Time
 }
 
 type metricExchange struct {
@@ -20,16 +30,56 @@ type metricExchange struct {
 	Updates chan *commandExecution
 	Mutex   *sync.RWMutex
 
+	// closeMutex guards closed so that no update is sent after Updates is closed.
+	closeMutex *sync.RWMutex
+	closed     bool
+	done       chan struct{}
+
+	dropPolicy string
+	clock      clock.Clock
+
+	// overflowMutex guards overflow, the counts of updates aggregated or dropped
+	// while Updates was full. overflowed signals the monitor to process them.
+	overflowMutex   *sync.Mutex
+	overflow        metricCollector.MetricResult
+	overflowPending bool
+	overflowed      chan struct{}
+
 	metricCollectors []metricCollector.MetricCollector
+	// workers receive batches of results for the collector at the same index.
+	workers     []chan []metricCollector.MetricResult
+	workersDone *sync.WaitGroup
 }
 
+const (
+	// metricsBatchSize is the most updates the monitor hands to the workers at once.
+	metricsBatchSize = 64
+	// metricsWorkerQueueSize is how many batches may wait for each collector.
+	metricsWorkerQueueSize = 16
+)
+
 func newMetricExchange(name string) *metricExchange {
+	settings := getSettings(name)
+
 	m := &metricExchange{}
 	m.Name = name
 
-	m.Updates = make(chan *commandExecution, 2000)
+	m.Updates = make(chan *commandExecution, settings.MetricsBufferSize)
 	m.Mutex = &sync.RWMutex{}
-	m.metricCollectors = metricCollector.Registry.InitializeMetricCollectors(name)
+	m.closeMutex = &sync.RWMutex{}
+	m.done = make(chan struct{})
+	m.dropPolicy = settings.MetricsDropPolicy
+	m.clock = clock.Default()
+	m.overflowMutex = &sync.Mutex{}
+	m.overflowed = make(chan struct{}, 1)
+	m.workersDone = &sync.WaitGroup{}
+	number, timing := rollingWindows(settings)
+	for _, collector := range metricCollector.Registry.InitializeMetricCollectors(name) {
+		if d, ok := collector.(*metricCollector.DefaultMetricCollector); ok {
+			d.SetRollingWindows(number, timing)
+		}
+		m.addCollector(collector)
+	}
 	m.Reset()
 
 	go m.Monitor()
@@ -37,6 +87,19 @@ func newMetricExchange(name string) *metricExchange {
 	return m
 }
 
+// rollingWindows returns the windows of a command's rolling counts and durations.
+func rollingWindows(settings *Settings) (number, timing rolling.Window) {
+	number = rolling.Window{
+		Length:  settings.MetricsRollingStatisticalWindow,
+		Buckets: settings.MetricsRollingStatisticalWindowBuckets,
+	}
+	timing = rolling.Window{
+		Length:  settings.MetricsRollingPercentileWindow,
+		Buckets: settings.MetricsRollingPercentileWindowBuckets,
+	}
+	return number, timing
+}
+
 // The Default Collector function will panic if collectors are not setup to specification.
 func (m *metricExchange) DefaultCollector() *metricCollector.DefaultMetricCollector {
 	if len(m.metricCollectors) < 1 {
@@ -49,30 +112,239 @@ func (m *metricExchange) DefaultCollector() *metricCollector.DefaultMetricCollec
 	return collection
 }
 
-func (m *metricExchange) Monitor() {
-	for update := range m.Updates {
+// addCollector starts a worker passing results to the collector.
+func (m *metricExchange) addCollector(collector metricCollector.MetricCollector) {
+	m.Mutex.Lock()
+	defer m.Mutex.Unlock()
+
+	batches := make(chan []metricCollector.MetricResult, metricsWorkerQueueSize)
+	m.metricCollectors = append(m.metricCollectors, collector)
+	m.workers = append(m.workers, batches)
+
+	m.workersDone.Add(1)
+	go m.work(collector, batches)
+}
+
+// work passes each batch of results to the collector, one result at a time,
+// until the batches channel is closed.
+func (m *metricExchange) work(collector metricCollector.MetricCollector, batches <-chan []metricCollector.MetricResult) {
+	defer m.workersDone.Done()
+
+	for batch := range batches {
 		// we only grab a read lock to make sure Reset() isn't changing the numbers.
 		m.Mutex.RLock()
+		for _, r := range batch {
+			collector.Update(r)
+		}
+		m.Mutex.RUnlock()
+	}
+}
+
+func (m *metricExchange) Monitor() {
+	defer close(m.done)
+	defer m.stopWorkers()
+
+	for {
+		select {
+		case update, ok := <-m.Updates:
+			if !ok {
+				m.processOverflow()
+				return
+			}
+
+			// take whatever else is already waiting, so that the workers
+			// receive it all at once.
+			size := 1 + len(m.Updates)
+			if size > metricsBatchSize {
+				size = metricsBatchSize
+			}
+			batch := make([]metricCollector.MetricResult, 0, size)
+			batch = append(batch, m.result(update))
+			closed := false
+		drain:
+			for len(batch) < metricsBatchSize {
+				select {
+				case update, ok := <-m.Updates:
+					if !ok {
+						closed = true
+						break drain
+					}
+					batch = append(batch, m.result(update))
+				default:
+					break drain
+				}
+			}
+			m.process(batch)
 
-		totalDuration := time.Since(update.Start)
-		wg := &sync.WaitGroup{}
-		for _, collector := range m.metricCollectors {
-			wg.Add(1)
-			go m.IncrementMetrics(wg, collector, update, totalDuration)
+			if closed {
+				m.processOverflow()
+				return
+			}
+		case <-m.overflowed:
+			m.processOverflow()
 		}
-		wg.Wait()
+	}
+}
 
-		m.Mutex.RUnlock()
+// result describes an update to the collectors, along with the state of the
+// exchange when the monitor received it.
+func (m *metricExchange) result(update *commandExecution) metricCollector.MetricResult {
+	now := m.clock.Now()
+	r := metricResult(update, now.Sub(update.Start))
+	if !update.queued.IsZero() {
+		r.MonitorLag = now.Sub(update.queued)
+	}
+	return r
+}
+
+// process hands a batch of results to every collector's worker. The batch is
+// shared between the workers and must not be modified afterwards.
+func (m *metricExchange) process(batch []metricCollector.MetricResult) {
+	m.Mutex.RLock()
+	workers := m.workers
+	m.Mutex.RUnlock()
+
+	for _, batches := range workers {
+		batches <- batch
 	}
 }
 
-func (m *metricExchange) IncrementMetrics(wg *sync.WaitGroup, collector metricCollector.MetricCollector, update *commandExecution, totalDuration time.Duration) {
+// stopWorkers waits for the workers to pass on every batch they were given.
+func (m *metricExchange) stopWorkers() {
+	m.Mutex.RLock()
+	workers := m.workers
+	m.Mutex.RUnlock()
+
+	for _, batches := range workers {
+		close(batches)
+	}
+	m.workersDone.Wait()
+}
+
+// processOverflow processes the updates aggregated or dropped while Updates was
+// full, if any.
+func (m *metricExchange) processOverflow() {
+	m.overflowMutex.Lock()
+	r, pending := m.overflow, m.overflowPending
+	m.overflow, m.overflowPending = metricCollector.MetricResult{}, false
+	m.overflowMutex.Unlock()
+
+	if pending {
+		r.Aggregated = true
+		m.process([]metricCollector.MetricResult{r})
+	}
+}
+
+// send queues an update for the monitor. When the channel is at capacity, the
+// exchange's drop policy decides whether to wait for room, aggregate the update
+// or drop it, in which case send returns false. Updates sent after Close are
+// silently discarded.
+func (m *metricExchange) send(update *commandExecution) bool {
+	m.closeMutex.RLock()
+	defer m.closeMutex.RUnlock()
+
+	if m.closed {
+		return true
+	}
+
+	update.queued = m.clock.Now()
+	if m.dropPolicy == MetricsBlock {
+		m.Updates <- update
+		return true
+	}
+
+	select {
+	case m.Updates <- update:
+		return true
+	default:
+	}
+
+	if m.dropPolicy == MetricsAggregate {
+		m.addOverflow(metricResult(update, 0))
+		return true
+	}
+
+	m.addOverflow(metricCollector.MetricResult{DroppedUpdates: 1})
+	return false
+}
+
+// addOverflow adds the counts of an update which didn't fit in the channel to
+// the overflow result, and signals the monitor to process it. Durations are
+// left out, as the result has none of its own.
+func (m *metricExchange) addOverflow(r metricCollector.MetricResult) {
+	r.TotalDuration, r.RunDuration = 0, 0
+
+	m.overflowMutex.Lock()
+	m.overflow = m.overflow.Add(r)
+	m.overflowPending = true
+	m.overflowMutex.Unlock()
+
+	select {
+	case m.overflowed <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops the monitor once the queued updates are processed, then closes
+// every collector which implements io.Closer.
+func (m *metricExchange) Close() {
+	m.closeMutex.Lock()
+	if m.closed {
+		m.closeMutex.Unlock()
+		return
+	}
+	m.closed = true
+	close(m.Updates)
+	m.closeMutex.Unlock()
+
+	<-m.done
+
+	for _, collector := range m.metricCollectors {
+		if c, ok := collector.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				log.Log(LogLevelError, "failed to close metric collector", LogField{"circuit", m.Name}, LogField{"error", err})
+			}
+		}
+	}
+}
+
+// admissionRejections are the events of executions which admission control
+// turned away on purpose. They never reached the dependency, so they don't count
+// towards the requests the circuit's health is judged by.
+var admissionRejections = map[string]bool{
+	"key-rejected":          true,
+	"rate-limited":          true,
+	"insufficient-deadline": true,
+	"slow-start":            true,
+	"shed":                  true,
+}
+
+// IsRejection reports whether eventType is the outcome of an execution which
+// was rejected before it ran, by the pool or by admission control. Executions
+// short-circuited by an open circuit are not counted as rejected.
+func IsRejection(eventType string) bool {
+	return eventType == "rejected" || admissionRejections[eventType]
+}
+
+// metricResult describes an execution to the collectors.
+func metricResult(update *commandExecution, totalDuration time.Duration) metricCollector.MetricResult {
 	// granular metrics
 	r := metricCollector.MetricResult{
 		Attempts:         1,
 		TotalDuration:    totalDuration,
+		RunStart:         update.RunStart,
 		RunDuration:      update.RunDuration,
 		ConcurrencyInUse: update.ConcurrencyInUse,
+		BulkheadKey:      update.BulkheadKey,
+		Criticality:      string(update.Criticality),
+		Tags:             update.Tags,
+	}
+
+	if update.FaultInjected {
+		r.FaultInjected = 1
+	}
+	if admissionRejections[update.Types[0]] {
+		r.Attempts = 0
 	}
 
 	switch update.Types[0] {
@@ -84,31 +356,55 @@ func (m *metricExchange) IncrementMetrics(wg *sync.WaitGroup, collector metricCo
 	case "rejected":
 		r.Rejects = 1
 		r.Errors = 1
+	case "key-rejected":
+		// a single key exceeding its share says nothing about the health of
+		// the dependency, so it is not counted as an error.
+		r.Rejects = 1
 	case "short-circuit":
 		r.ShortCircuits = 1
 		r.Errors = 1
 	case "timeout":
 		r.Timeouts = 1
 		r.Errors = 1
+	case "rate-limited":
+		r.RateLimited = 1
+	case "insufficient-deadline":
+		r.InsufficientDeadline = 1
+	case "slow-start":
+		// the ramp turns executions away on purpose, so they must not count
+		// as errors which would open the circuit again.
+		r.SlowStartRejects = 1
+	case "shed":
+		// the pool keeps its last tickets for critical work on purpose, so
+		// shedding the rest says nothing about the health of the dependency.
+		r.Shed = 1
 	case "context_canceled":
 		r.ContextCanceled = 1
 	case "context_deadline_exceeded":
 		r.ContextDeadlineExceeded = 1
 	}
 
-	if len(update.Types) > 1 {
-		// fallback metrics
-		if update.Types[1] == "fallback-success" {
+	// the events after the outcome describe the fallback and, in shadow mode,
+	// what the circuit would have done instead.
+	for _, eventType := range update.Types[1:] {
+		switch eventType {
+		case "fallback-success":
 			r.FallbackSuccesses = 1
-		}
-		if update.Types[1] == "fallback-failure" {
+		case "fallback-stale":
+			r.FallbackSuccesses = 1
+			r.FallbackStale = 1
+		case "fallback-failure":
 			r.FallbackFailures = 1
+		case "shadow-short-circuit":
+			r.ShadowShortCircuits = 1
+		case "shadow-rejected", "shadow-key-rejected", "shadow-rate-limited", "shadow-insufficient-deadline", "shadow-slow-start", "shadow-shed":
+			r.ShadowRejects = 1
+		case "shadow-timeout":
+			r.ShadowTimeouts = 1
 		}
 	}
 
-	collector.Update(r)
-
-	wg.Done()
+	return r
 }
 
 func (m *metricExchange) Reset() {
@@ -1,9 +1,13 @@
 package hystrix
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/afex/hystrix-go/hystrix/clock"
+	"github.com/afex/hystrix-go/hystrix/metric_collector"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -43,3 +47,231 @@ func TestErrorPercent(t *testing.T) {
 		})
 	})
 }
+
+func TestAdmissionRejectionHealth(t *testing.T) {
+	Convey("with a circuit failing 60 percent of the time", t, func() {
+		ConfigureCommand("rejection_health", CommandConfig{ErrorPercentThreshold: 50})
+		m := newMetricExchange("rejection_health")
+		defer m.Close()
+
+		update := func(eventType string, n int) {
+			for i := 0; i < n; i++ {
+				m.DefaultCollector().Update(metricResult(&commandExecution{Types: []string{eventType}}, 0))
+			}
+		}
+		update("failure", 6)
+		update("success", 4)
+
+		Convey("executions turned away before running leave its health alone", func() {
+			for eventType := range admissionRejections {
+				update(eventType, 10)
+			}
+
+			now := time.Now()
+			So(m.Requests().Sum(now), ShouldEqual, 10)
+			So(m.ErrorPercent(now), ShouldEqual, 60)
+			So(m.IsHealthy(now), ShouldBeFalse)
+		})
+	})
+}
+
+func TestRejectsByKey(t *testing.T) {
+	Convey("with a collector which has seen rejections for two bulkhead keys", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		defer Flush()
+
+		m := newMetricExchange("")
+		defer m.Close()
+		collector := m.DefaultCollector()
+		collector.Update(metricCollector.MetricResult{Rejects: 1, BulkheadKey: "noisy"})
+		collector.Update(metricCollector.MetricResult{Rejects: 1, BulkheadKey: "noisy"})
+		collector.Update(metricCollector.MetricResult{Rejects: 1, BulkheadKey: "quiet"})
+		collector.Update(metricCollector.MetricResult{Successes: 1, BulkheadKey: "idle"})
+
+		Convey("the rejections are counted per key", func() {
+			So(collector.RejectsByKey(fake.Now()), ShouldResemble, map[string]float64{"noisy": 2, "quiet": 1})
+		})
+
+		Convey("keys are forgotten once their rejections leave the window", func() {
+			fake.Advance(11 * time.Second)
+			So(collector.RejectsByKey(fake.Now()), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestMetricsDropPolicy(t *testing.T) {
+	Convey("with a metrics buffer of a single update and a stalled monitor", t, func() {
+		send := func(m *metricExchange, n int) int {
+			sent := 0
+			for i := 0; i < n; i++ {
+				start := time.Now().Add(-10 * time.Millisecond)
+				if m.send(&commandExecution{Types: []string{"success"}, Start: start, RunStart: start, RunDuration: 10 * time.Millisecond}) {
+					sent++
+				}
+			}
+			return sent
+		}
+		// stall holds up the monitor once it has taken an update, so that the
+		// next one waits in the buffer.
+		stall := func(m *metricExchange) {
+			m.Mutex.Lock()
+			send(m, 1)
+			for len(m.Updates) > 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+		recorder := resultRecorder{results: make(chan metricCollector.MetricResult, 10)}
+
+		Convey("the drop policy counts the updates left out as soon as they are dropped", func() {
+			ConfigureCommand("metrics_policy", CommandConfig{MetricsBufferSize: 1, MetricsDropPolicy: MetricsDrop})
+			m := newMetricExchange("metrics_policy")
+			defer m.Close()
+			m.addCollector(recorder)
+
+			stall(m)
+			sent := send(m, 9)
+			stalled := time.Now()
+			So(sent, ShouldEqual, 1)
+			So(len(m.overflowed), ShouldEqual, 1)
+			lag := time.Since(stalled)
+			m.Mutex.Unlock()
+
+			var total metricCollector.MetricResult
+			for i := 0; i < 3; i++ {
+				r := <-recorder.results
+				if r.Aggregated {
+					So(r.DroppedUpdates, ShouldEqual, 8)
+					So(r.Successes, ShouldEqual, 0)
+				}
+				total = total.Add(r)
+			}
+			So(total.Successes, ShouldEqual, 2)
+			So(total.MonitorLag, ShouldBeGreaterThanOrEqualTo, lag)
+
+			// closing waits for every collector to receive the results
+			m.Close()
+			So(m.DefaultCollector().DroppedUpdates().Sum(time.Now()), ShouldEqual, 8)
+		})
+
+		Convey("the aggregate policy keeps the counts of every update", func() {
+			ConfigureCommand("metrics_policy", CommandConfig{MetricsBufferSize: 1, MetricsDropPolicy: MetricsAggregate})
+			m := newMetricExchange("metrics_policy")
+			defer m.Close()
+			m.addCollector(recorder)
+
+			stall(m)
+			sent := send(m, 9)
+			m.Mutex.Unlock()
+			So(sent, ShouldEqual, 9)
+
+			var total metricCollector.MetricResult
+			for i := 0; i < 3; i++ {
+				total = total.Add(<-recorder.results)
+			}
+			So(total.Attempts, ShouldEqual, 10)
+			So(total.Successes, ShouldEqual, 10)
+			So(total.DroppedUpdates, ShouldEqual, 0)
+
+			Convey("without recording the aggregated updates as instant executions", func() {
+				m.Close()
+				now := time.Now()
+				So(m.DefaultCollector().NumRequests().Sum(now), ShouldEqual, 10)
+				So(m.DefaultCollector().RunDuration().Snapshot(now).Percentile(0), ShouldEqual, 10*time.Millisecond)
+				So(m.DefaultCollector().TotalDuration().Snapshot(now).Percentile(0), ShouldBeGreaterThanOrEqualTo, 10*time.Millisecond)
+			})
+		})
+
+		Convey("the block policy waits for room", func() {
+			ConfigureCommand("metrics_policy", CommandConfig{MetricsBufferSize: 1, MetricsDropPolicy: MetricsBlock})
+			m := newMetricExchange("metrics_policy")
+			defer m.Close()
+			m.addCollector(recorder)
+
+			stall(m)
+			done := make(chan int)
+			go func() { done <- send(m, 9) }()
+
+			time.Sleep(10 * time.Millisecond)
+			select {
+			case <-done:
+				t.Error("sending did not block while the buffer was full")
+			default:
+			}
+			m.Mutex.Unlock()
+			So(<-done, ShouldEqual, 9)
+
+			var total metricCollector.MetricResult
+			for total.Successes < 10 {
+				total = total.Add(<-recorder.results)
+			}
+			So(total.Successes, ShouldEqual, 10)
+		})
+	})
+}
+
+// runDurationRecorder records the run duration of every result, in the order received.
+type runDurationRecorder struct {
+	mutex     *sync.Mutex
+	durations []time.Duration
+}
+
+func (r *runDurationRecorder) Update(result metricCollector.MetricResult) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.durations = append(r.durations, result.RunDuration)
+}
+
+func (r *runDurationRecorder) Reset() {}
+
+func TestMetricWorkers(t *testing.T) {
+	Convey("with several collectors receiving a circuit's metrics", t, func() {
+		ConfigureCommand("metrics_workers", CommandConfig{MetricsDropPolicy: MetricsBlock})
+		m := newMetricExchange("metrics_workers")
+		recorders := []*runDurationRecorder{{mutex: &sync.Mutex{}}, {mutex: &sync.Mutex{}}}
+		for _, recorder := range recorders {
+			m.addCollector(recorder)
+		}
+
+		Convey("each collector receives every result in the order they were sent", func() {
+			var want []time.Duration
+			for i := 0; i < 500; i++ {
+				want = append(want, time.Duration(i))
+				m.send(&commandExecution{Types: []string{"success"}, Start: time.Now(), RunDuration: time.Duration(i)})
+			}
+			m.Close()
+
+			for _, recorder := range recorders {
+				So(recorder.durations, ShouldResemble, want)
+			}
+			So(m.DefaultCollector().Successes().Sum(time.Now()), ShouldEqual, 500)
+		})
+	})
+}
+
+type discardCollector struct{}
+
+func (discardCollector) Update(metricCollector.MetricResult) {}
+func (discardCollector) Reset()                              {}
+
+func BenchmarkMetricExchange(b *testing.B) {
+	for _, collectors := range []int{1, 4} {
+		b.Run(fmt.Sprintf("collectors=%d", collectors), func(b *testing.B) {
+			ConfigureCommand("benchmark", CommandConfig{MetricsDropPolicy: MetricsBlock})
+			m := newMetricExchange("benchmark")
+			for i := 1; i < collectors; i++ {
+				m.addCollector(discardCollector{})
+			}
+
+			start := time.Now()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m.send(&commandExecution{Types: []string{"success"}, Start: start})
+			}
+			// wait for every update to reach the collectors
+			m.Close()
+		})
+	}
+}
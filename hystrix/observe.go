@@ -0,0 +1,179 @@
+package hystrix
+
+import (
+	"context"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+)
+
+type observeFuncC func(context.Context, func(interface{}) error) error
+type observeFallbackFuncC func(context.Context, error, func(interface{}) error) error
+
+// ObserveC runs a command which produces a stream of values, such as a paginated
+// API or a server stream. Your run function passes each value to emit, which
+// returns an error once the stream has been abandoned; run should then return.
+//
+// An error from run, or a timeout, ends the stream and the fallback, if any,
+// continues it by emitting its own values. Timeout applies to the whole stream,
+// while StreamFirstItemTimeout and StreamItemTimeout bound the wait for each
+// value. The circuit learns whether the stream succeeded once it ends.
+//
+// The values channel is closed when the stream ends. The error channel then
+// receives the error the stream failed with, if any, and is closed.
+func ObserveC(ctx context.Context, name string, run observeFuncC, fallback observeFallbackFuncC) (<-chan interface{}, <-chan error) {
+	values := make(chan interface{})
+	errs := make(chan error, 1)
+
+	cmd := newCommand(ctx, nil, nil)
+	cmd.errChan = errs
+	if fallback != nil {
+		cmd.fallback = func(ctx context.Context, err error) error {
+			return fallback(ctx, err, func(v interface{}) error {
+				select {
+				case values <- v:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+		}
+	}
+
+	go func() {
+		defer close(errs)
+		defer close(values)
+
+		circuit, err := acquireCircuit(name)
+		if err != nil {
+			errs <- err
+			return
+		}
+		cmd.circuit = circuit
+		ctx := cmd.startTrace(ctx, name)
+
+		if err := cmd.admit(ctx); err != nil {
+			cmd.releaseTicket()
+			cmd.errorWithFallback(ctx, err)
+			cmd.reportAllEvent()
+			return
+		}
+
+		runCtx, endRun := cmd.startRunTrace(ctx)
+		runErr := cmd.injectFaults(runCtx)
+		if runErr == nil {
+			runErr = cmd.observe(runCtx, run, values)
+		}
+		endRun(runErr)
+		cmd.releaseTicket()
+		if runErr != nil {
+			cmd.errorWithFallback(ctx, runErr)
+		} else {
+			cmd.reportEvent("success")
+		}
+		cmd.reportAllEvent()
+	}()
+
+	return values, errs
+}
+
+// observe runs the stream, forwarding its values until it ends, and returns
+// the error it ended with. Only this goroutine writes to values, so nothing
+// from an abandoned run can be interleaved with the fallback's values.
+func (c *command) observe(ctx context.Context, run observeFuncC, values chan<- interface{}) error {
+	settings := getSettings(c.circuit.Name)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	items := make(chan interface{})
+	finished := make(chan error, 1)
+	runStart := c.circuit.clock.Now()
+	defer func() { c.runStart, c.runDuration = runStart, c.since(runStart) }()
+
+	go func() {
+		finished <- run(runCtx, func(v interface{}) error {
+			select {
+			case items <- v:
+				return nil
+			case <-runCtx.Done():
+				return runCtx.Err()
+			}
+		})
+	}()
+
+	timer := c.circuit.clock.NewTimer(settings.Timeout)
+	defer timer.Stop()
+	timeout := timer.C()
+
+	// timedOut decides whether a timeout ends the stream. In shadow mode the
+	// first one is only recorded, and the stream carries on.
+	shadowTimedOut := false
+	timedOut := func() bool {
+		if !settings.ShadowMode {
+			return true
+		}
+		if !shadowTimedOut {
+			shadowTimedOut = true
+			c.recordShadowEvent(ErrTimeout)
+		}
+		return false
+	}
+
+	// the wait for each value is timed from when it starts, so the timer is
+	// only replaced once a value arrives.
+	var itemTimer clock.Timer
+	var itemTimedOut <-chan time.Time
+	stopItemTimer := func() {
+		if itemTimer != nil {
+			itemTimer.Stop()
+		}
+		itemTimer, itemTimedOut = nil, nil
+	}
+	startItemTimer := func(d time.Duration) {
+		stopItemTimer()
+		if d > 0 {
+			itemTimer = c.circuit.clock.NewTimer(d)
+			itemTimedOut = itemTimer.C()
+		}
+	}
+	defer stopItemTimer()
+
+	startItemTimer(settings.StreamFirstItemTimeout)
+	for {
+		select {
+		case v := <-items:
+			stopItemTimer()
+			// a slow consumer counts against the stream's timeout, but not
+			// against the wait for the next value.
+			for sent := false; !sent; {
+				select {
+				case values <- v:
+					sent = true
+				case <-timeout:
+					if timedOut() {
+						return ErrTimeout
+					}
+					timeout = nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			startItemTimer(settings.StreamItemTimeout)
+		case err := <-finished:
+			return err
+		case <-itemTimedOut:
+			if timedOut() {
+				return ErrTimeout
+			}
+			stopItemTimer()
+		case <-timeout:
+			if timedOut() {
+				return ErrTimeout
+			}
+			timeout = nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
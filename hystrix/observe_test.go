@@ -0,0 +1,147 @@
+package hystrix
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+	"github.com/afex/hystrix-go/hystrix/metric_collector"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func collectStream(values <-chan interface{}, errs <-chan error) ([]interface{}, error) {
+	var out []interface{}
+	for v := range values {
+		out = append(out, v)
+	}
+	return out, <-errs
+}
+
+func TestObserve(t *testing.T) {
+	Convey("with a stream which emits 3 values", t, func() {
+		defer Flush()
+		recorder := resultRecorder{results: make(chan metricCollector.MetricResult, 1)}
+		cb, _, _ := GetCircuit("")
+		cb.metrics.addCollector(recorder)
+
+		values, errs := ObserveC(context.Background(), "", func(ctx context.Context, emit func(interface{}) error) error {
+			for i := 1; i <= 3; i++ {
+				if err := emit(i); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil)
+
+		Convey("every value is received, followed by no error", func() {
+			out, err := collectStream(values, errs)
+			So(out, ShouldResemble, []interface{}{1, 2, 3})
+			So(err, ShouldBeNil)
+
+			Convey("and a single success is recorded", func() {
+				So((<-recorder.results).Successes, ShouldEqual, 1)
+				So(cb.executorPool.ActiveCount(), ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("with a stream which fails part way through", t, func() {
+		defer Flush()
+		recorder := resultRecorder{results: make(chan metricCollector.MetricResult, 1)}
+		cb, _, _ := GetCircuit("")
+		cb.metrics.addCollector(recorder)
+
+		values, errs := ObserveC(context.Background(), "", func(ctx context.Context, emit func(interface{}) error) error {
+			emit("primary")
+			return fmt.Errorf("broken")
+		}, func(ctx context.Context, err error, emit func(interface{}) error) error {
+			emit("fallback")
+			return nil
+		})
+
+		Convey("the fallback continues the stream", func() {
+			out, err := collectStream(values, errs)
+			So(out, ShouldResemble, []interface{}{"primary", "fallback"})
+			So(err, ShouldBeNil)
+
+			Convey("and the failure is recorded", func() {
+				result := <-recorder.results
+				So(result.Failures, ShouldEqual, 1)
+				So(result.FallbackSuccesses, ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("with a stream whose first value is too slow", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		defer Flush()
+		ConfigureCommand("", CommandConfig{StreamFirstItemTimeout: 10})
+
+		values, errs := ObserveC(context.Background(), "", func(ctx context.Context, emit func(interface{}) error) error {
+			<-ctx.Done()
+			return emit("late")
+		}, nil)
+
+		Convey("the stream times out without the late value", func() {
+			// the command's timeout, and the first value's
+			fake.WaitForTimers(2)
+			fake.Advance(10 * time.Millisecond)
+
+			out, err := collectStream(values, errs)
+			So(out, ShouldBeEmpty)
+			So(err, ShouldResemble, ErrTimeout)
+		})
+	})
+
+	Convey("with a stream which stalls between values", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		defer Flush()
+		ConfigureCommand("", CommandConfig{StreamItemTimeout: 10})
+
+		values, errs := ObserveC(context.Background(), "", func(ctx context.Context, emit func(interface{}) error) error {
+			emit(1)
+			<-ctx.Done()
+			return ctx.Err()
+		}, nil)
+
+		Convey("the stream times out after the first value", func() {
+			go func() {
+				// the command's timeout, and the next value's
+				fake.WaitForTimers(2)
+				fake.Advance(10 * time.Millisecond)
+			}()
+			out, err := collectStream(values, errs)
+			So(out, ShouldResemble, []interface{}{1})
+			So(err, ShouldResemble, ErrTimeout)
+		})
+	})
+
+	Convey("with a stream which emits for longer than its timeout", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		defer Flush()
+		ConfigureCommand("", CommandConfig{Timeout: 30, StreamItemTimeout: 20})
+
+		values, errs := ObserveC(context.Background(), "", func(ctx context.Context, emit func(interface{}) error) error {
+			for {
+				fake.Advance(5 * time.Millisecond)
+				if err := emit(struct{}{}); err != nil {
+					return err
+				}
+			}
+		}, nil)
+
+		Convey("the whole stream times out", func() {
+			out, err := collectStream(values, errs)
+			So(len(out), ShouldBeGreaterThan, 0)
+			So(err, ShouldResemble, ErrTimeout)
+		})
+	})
+}
@@ -1,10 +1,16 @@
 package hystrix
 
+import "sync"
+
 type executorPool struct {
-	Name    string
-	Metrics *poolMetrics
-	Max     int
-	Tickets chan *struct{}
+	Name           string
+	Metrics        *poolMetrics
+	Max            int
+	ReservePercent int
+	Tickets        chan *struct{}
+	// takeMutex makes checking the criticality limit and taking a ticket one step.
+	// Pools without a reserve have no limit to check, and take without it.
+	takeMutex *sync.Mutex
 }
 
 func newExecutorPool(name string) *executorPool {
@@ -12,6 +18,8 @@ func newExecutorPool(name string) *executorPool {
 	p.Name = name
 	p.Metrics = newPoolMetrics(name)
 	p.Max = getSettings(name).MaxConcurrentRequests
+	p.ReservePercent = getSettings(name).PriorityReservePercent
+	p.takeMutex = &sync.Mutex{}
 
 	p.Tickets = make(chan *struct{}, p.Max)
 	for i := 0; i < p.Max; i++ {
@@ -21,17 +29,71 @@ func newExecutorPool(name string) *executorPool {
 	return p
 }
 
+// take returns a ticket for an execution of the given criticality. It fails with
+// ErrMaxConcurrency when the pool is exhausted, or ErrLoadShed when the tickets
+// left are reserved for more critical executions.
+func (p *executorPool) take(c Criticality) (*struct{}, error) {
+	if p.ReservePercent <= 0 {
+		return p.takeTicket()
+	}
+
+	p.takeMutex.Lock()
+	defer p.takeMutex.Unlock()
+
+	// every take from a reserved pool holds the mutex, so no other execution can
+	// use up the room between the check and the take. Returned tickets only add to it.
+	if p.ActiveCount() >= p.Limit(c) {
+		if len(p.Tickets) == 0 {
+			return nil, ErrMaxConcurrency
+		}
+		return nil, ErrLoadShed
+	}
+
+	return p.takeTicket()
+}
+
+func (p *executorPool) takeTicket() (*struct{}, error) {
+	select {
+	case ticket := <-p.Tickets:
+		return ticket, nil
+	default:
+		return nil, ErrMaxConcurrency
+	}
+}
+
 func (p *executorPool) Return(ticket *struct{}) {
 	if ticket == nil {
 		return
 	}
 
-	p.Metrics.Updates <- poolMetricsUpdate{
+	p.Metrics.update(poolMetricsUpdate{
 		activeCount: p.ActiveCount(),
-	}
+	})
 	p.Tickets <- ticket
 }
 
 func (p *executorPool) ActiveCount() int {
 	return p.Max - len(p.Tickets)
 }
+
+// Limit returns how many tickets executions of the given criticality may hold.
+// Each criticality above sheddable has ReservePercent of the pool reserved for it,
+// so sheddable work is rejected first, then default work, as the pool fills up.
+func (p *executorPool) Limit(c Criticality) int {
+	reserved := 0
+	switch c {
+	case CriticalitySheddable:
+		reserved = 2 * p.ReservePercent
+	case CriticalityCritical:
+		reserved = 0
+	default:
+		reserved = p.ReservePercent
+	}
+
+	// round to the nearest ticket so that small pools don't reject everything below critical.
+	limit := (p.Max*(100-reserved) + 50) / 100
+	if limit < 0 {
+		return 0
+	}
+	return limit
+}
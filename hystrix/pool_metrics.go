@@ -3,6 +3,7 @@ package hystrix
 import (
 	"sync"
 
+	"github.com/afex/hystrix-go/hystrix/clock"
 	"github.com/afex/hystrix-go/hystrix/rolling"
 )
 
@@ -10,9 +11,15 @@ type poolMetrics struct {
 	Mutex   *sync.RWMutex
 	Updates chan poolMetricsUpdate
 
+	// closeMutex guards closed so that no update is sent after Updates is closed.
+	closeMutex *sync.RWMutex
+	closed     bool
+	done       chan struct{}
+
 	Name              string
 	MaxActiveRequests *rolling.Number
 	Executed          *rolling.Number
+	clock             clock.Clock
 }
 
 type poolMetricsUpdate struct {
@@ -24,6 +31,9 @@ func newPoolMetrics(name string) *poolMetrics {
 	m.Name = name
 	m.Updates = make(chan poolMetricsUpdate)
 	m.Mutex = &sync.RWMutex{}
+	m.closeMutex = &sync.RWMutex{}
+	m.done = make(chan struct{})
+	m.clock = clock.Default()
 
 	m.Reset()
 
@@ -36,11 +46,39 @@ func (m *poolMetrics) Reset() {
 	m.Mutex.Lock()
 	defer m.Mutex.Unlock()
 
-	m.MaxActiveRequests = rolling.NewNumber()
-	m.Executed = rolling.NewNumber()
+	window, _ := rollingWindows(getSettings(m.Name))
+	m.MaxActiveRequests = rolling.NewNumberWithWindow(window, m.clock)
+	m.Executed = rolling.NewNumberWithWindow(window, m.clock)
+}
+
+// update hands an update to the monitor, discarding it once the metrics are closed.
+func (m *poolMetrics) update(u poolMetricsUpdate) {
+	m.closeMutex.RLock()
+	defer m.closeMutex.RUnlock()
+
+	if m.closed {
+		return
+	}
+	m.Updates <- u
+}
+
+// Close stops the monitor goroutine.
+func (m *poolMetrics) Close() {
+	m.closeMutex.Lock()
+	if m.closed {
+		m.closeMutex.Unlock()
+		return
+	}
+	m.closed = true
+	close(m.Updates)
+	m.closeMutex.Unlock()
+
+	<-m.done
 }
 
 func (m *poolMetrics) Monitor() {
+	defer close(m.done)
+
 	for u := range m.Updates {
 		m.Mutex.RLock()
 
@@ -1,6 +1,7 @@
 package hystrix
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -44,3 +45,87 @@ func TestActiveCount(t *testing.T) {
 		})
 	})
 }
+
+func TestLimit(t *testing.T) {
+	defer Flush()
+
+	Convey("given a pool of 10 with 20 percent reserved per criticality", t, func() {
+		ConfigureCommand("reserved", CommandConfig{MaxConcurrentRequests: 10, PriorityReservePercent: 20})
+		pool := newExecutorPool("reserved")
+
+		Convey("critical work may use every ticket", func() {
+			So(pool.Limit(CriticalityCritical), ShouldEqual, 10)
+		})
+
+		Convey("default work may use 80 percent", func() {
+			So(pool.Limit(CriticalityDefault), ShouldEqual, 8)
+		})
+
+		Convey("sheddable work may use 60 percent", func() {
+			So(pool.Limit(CriticalitySheddable), ShouldEqual, 6)
+		})
+	})
+
+	Convey("given a pool without a reserve", t, func() {
+		ConfigureCommand("unreserved", CommandConfig{MaxConcurrentRequests: 10})
+		pool := newExecutorPool("unreserved")
+
+		Convey("every criticality may use every ticket", func() {
+			So(pool.Limit(CriticalitySheddable), ShouldEqual, 10)
+			So(pool.Limit(CriticalityDefault), ShouldEqual, 10)
+		})
+	})
+}
+
+func TestTake(t *testing.T) {
+	defer Flush()
+
+	Convey("given a pool of 2 without a reserve", t, func() {
+		ConfigureCommand("unreserved", CommandConfig{MaxConcurrentRequests: 2})
+		pool := newExecutorPool("unreserved")
+
+		Convey("sheddable work may take every ticket, and is then rejected", func() {
+			_, err := pool.take(CriticalitySheddable)
+			So(err, ShouldBeNil)
+			_, err = pool.take(CriticalitySheddable)
+			So(err, ShouldBeNil)
+			_, err = pool.take(CriticalitySheddable)
+			So(err, ShouldResemble, ErrMaxConcurrency)
+		})
+	})
+
+	Convey("given a pool of 2 with 50 percent reserved per criticality", t, func() {
+		ConfigureCommand("reserved", CommandConfig{MaxConcurrentRequests: 2, PriorityReservePercent: 50})
+		pool := newExecutorPool("reserved")
+
+		Convey("default work leaves the reserved ticket for critical work", func() {
+			_, err := pool.take(CriticalityDefault)
+			So(err, ShouldBeNil)
+			_, err = pool.take(CriticalityDefault)
+			So(err, ShouldResemble, ErrLoadShed)
+			_, err = pool.take(CriticalityCritical)
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
+func BenchmarkExecutorPoolTake(b *testing.B) {
+	for _, reserve := range []int{0, 20} {
+		b.Run(fmt.Sprintf("reserve=%d", reserve), func(b *testing.B) {
+			ConfigureCommand("benchmark", CommandConfig{MaxConcurrentRequests: 1000, PriorityReservePercent: reserve})
+			defer Flush()
+			pool := newExecutorPool("benchmark")
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					ticket, err := pool.take(CriticalityDefault)
+					if err != nil {
+						b.Fatal(err)
+					}
+					pool.Tickets <- ticket
+				}
+			})
+		})
+	}
+}
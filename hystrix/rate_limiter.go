@@ -0,0 +1,82 @@
+package hystrix
+
+import (
+	"sync"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+)
+
+// rateLimiter is a token bucket which admits at most Rate executions per second,
+// allowing bursts of up to Burst executions.
+type rateLimiter struct {
+	Name  string
+	Rate  float64
+	Burst float64
+
+	mutex  *sync.Mutex
+	tokens float64
+	last   time.Time
+	clock  clock.Clock
+}
+
+// newRateLimiter returns the rate limiter for the named command, or nil if the
+// command has no RequestsPerSecond configured.
+func newRateLimiter(name string) *rateLimiter {
+	settings := getSettings(name)
+	if settings.RequestsPerSecond <= 0 {
+		return nil
+	}
+
+	l := &rateLimiter{}
+	l.Name = name
+	l.Rate = float64(settings.RequestsPerSecond)
+	l.Burst = float64(settings.Burst)
+	l.mutex = &sync.Mutex{}
+	l.tokens = l.Burst
+	l.clock = clock.Default()
+	l.last = l.clock.Now()
+
+	return l
+}
+
+// Allow takes a token from the bucket, returning false if none is available.
+// A nil rateLimiter allows everything.
+func (l *rateLimiter) Allow() bool {
+	if l == nil {
+		return true
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.clock.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.Rate
+	if l.tokens > l.Burst {
+		l.tokens = l.Burst
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+
+	return true
+}
+
+// Refund gives back a token taken by Allow, for an execution which was turned
+// away by a later check and never ran.
+func (l *rateLimiter) Refund() {
+	if l == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.tokens++
+	if l.tokens > l.Burst {
+		l.tokens = l.Burst
+	}
+}
@@ -0,0 +1,45 @@
+package hystrix
+
+import (
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	Convey("given a rate limiter of 10 per second with a burst of 2", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+
+		ConfigureCommand("limited", CommandConfig{RequestsPerSecond: 10, Burst: 2})
+		l := newRateLimiter("limited")
+
+		Convey("the burst is allowed immediately", func() {
+			So(l.Allow(), ShouldBeTrue)
+			So(l.Allow(), ShouldBeTrue)
+
+			Convey("and the next call is rejected", func() {
+				So(l.Allow(), ShouldBeFalse)
+			})
+
+			Convey("and a token is refilled after 100ms", func() {
+				fake.Advance(100 * time.Millisecond)
+				So(l.Allow(), ShouldBeTrue)
+				So(l.Allow(), ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("given a command without a rate limit", t, func() {
+		ConfigureCommand("unlimited", CommandConfig{})
+		l := newRateLimiter("unlimited")
+
+		Convey("no rate limiter is created and everything is allowed", func() {
+			So(l, ShouldBeNil)
+			So(l.Allow(), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,80 @@
+package hystrix
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type requestLogContextKey struct{}
+
+// RequestLogEntry describes one command execution in a RequestLog.
+type RequestLogEntry struct {
+	Name          string
+	Events        []string
+	RunDuration   time.Duration
+	TotalDuration time.Duration
+}
+
+// A RequestLog records every command executed under a context, such as the
+// context of one inbound request, in the order they finished.
+type RequestLog struct {
+	mutex   *sync.Mutex
+	entries []RequestLogEntry
+}
+
+// WithRequestLog returns a context whose command executions are recorded in the
+// returned RequestLog. Synchronous executions, such as DoC, are recorded by the
+// time they return. Executions started with GoC are recorded shortly after they
+// finish, which may be after their error channel is written to.
+func WithRequestLog(ctx context.Context) (context.Context, *RequestLog) {
+	l := &RequestLog{}
+	l.mutex = &sync.Mutex{}
+
+	return context.WithValue(ctx, requestLogContextKey{}, l), l
+}
+
+// RequestLogFromContext returns the RequestLog of ctx, or nil if it has none.
+func RequestLogFromContext(ctx context.Context) *RequestLog {
+	l, _ := ctx.Value(requestLogContextKey{}).(*RequestLog)
+	return l
+}
+
+// add records an entry. A nil RequestLog records nothing.
+func (l *RequestLog) add(entry RequestLogEntry) {
+	if l == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.entries = append(l.entries, entry)
+}
+
+// Entries returns a copy of the executions recorded so far.
+func (l *RequestLog) Entries() []RequestLogEntry {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return append([]RequestLogEntry(nil), l.entries...)
+}
+
+// String formats the log like Hystrix's HystrixRequestLog, for example
+// "CommandA[SUCCESS][15ms], CommandB[TIMEOUT, FALLBACK_SUCCESS][1000ms]".
+func (l *RequestLog) String() string {
+	entries := l.Entries()
+
+	formatted := make([]string, len(entries))
+	for i, entry := range entries {
+		events := make([]string, len(entry.Events))
+		for j, event := range entry.Events {
+			events[j] = strings.ToUpper(strings.Replace(event, "-", "_", -1))
+		}
+		formatted[i] = fmt.Sprintf("%v[%v][%vms]", entry.Name, strings.Join(events, ", "), entry.TotalDuration.Nanoseconds()/1000000)
+	}
+
+	return strings.Join(formatted, ", ")
+}
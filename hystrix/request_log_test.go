@@ -0,0 +1,73 @@
+package hystrix
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRequestLog(t *testing.T) {
+	Convey("with a context with a request log", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		defer Flush()
+		ConfigureCommand("slow", CommandConfig{Timeout: 10})
+
+		ctx, requestLog := WithRequestLog(context.Background())
+
+		Convey("every command executed under it is recorded in order", func() {
+			err := DoC(ctx, "fast", func(ctx context.Context) error {
+				return nil
+			}, nil)
+			So(err, ShouldBeNil)
+
+			release := make(chan struct{})
+			defer close(release)
+			go func() {
+				fake.WaitForTimers(1)
+				fake.Advance(10 * time.Millisecond)
+			}()
+			err = DoC(ctx, "slow", func(ctx context.Context) error {
+				<-release
+				return nil
+			}, func(ctx context.Context, err error) error {
+				return nil
+			})
+			So(err, ShouldBeNil)
+
+			err = DoC(ctx, "fast", func(ctx context.Context) error {
+				return fmt.Errorf("run_error")
+			}, nil)
+			So(err, ShouldNotBeNil)
+
+			entries := requestLog.Entries()
+			So(len(entries), ShouldEqual, 3)
+			So(entries[0].Name, ShouldEqual, "fast")
+			So(entries[0].Events, ShouldResemble, []string{"success"})
+			So(entries[1].Name, ShouldEqual, "slow")
+			So(entries[1].Events, ShouldResemble, []string{"timeout", "fallback-success"})
+			So(entries[1].TotalDuration, ShouldBeGreaterThanOrEqualTo, 10*time.Millisecond)
+			So(entries[2].Events, ShouldResemble, []string{"failure"})
+		})
+
+		Convey("it can be found on the context", func() {
+			So(RequestLogFromContext(ctx), ShouldEqual, requestLog)
+			So(RequestLogFromContext(context.Background()), ShouldBeNil)
+		})
+	})
+
+	Convey("with a request log holding executions", t, func() {
+		_, requestLog := WithRequestLog(context.Background())
+		requestLog.add(RequestLogEntry{Name: "CommandA", Events: []string{"success"}, TotalDuration: 15 * time.Millisecond})
+		requestLog.add(RequestLogEntry{Name: "CommandB", Events: []string{"timeout", "fallback-success"}, TotalDuration: time.Second})
+
+		Convey("it is formatted like Hystrix's request log", func() {
+			So(requestLog.String(), ShouldEqual, "CommandA[SUCCESS][15ms], CommandB[TIMEOUT, FALLBACK_SUCCESS][1000ms]")
+		})
+	})
+}
@@ -0,0 +1,51 @@
+package hystrix
+
+import (
+	"context"
+	"time"
+)
+
+// ExecutionResult describes how a command execution went, so that callers can
+// tell whether they were served by run or by the fallback.
+type ExecutionResult struct {
+	// Events are the events reported for the execution, such as "success" or
+	// "timeout" followed by "fallback-success".
+	Events []string
+	// RunDuration is how long run took, or zero if it did not finish in time.
+	RunDuration time.Duration
+	// TotalDuration is how long the caller waited for the execution.
+	TotalDuration time.Duration
+	// FallbackRan is true when the fallback was called.
+	FallbackRan bool
+	// FallbackErr is the error returned by the fallback, if any.
+	FallbackErr error
+	// CircuitState is the state of the circuit when the execution was admitted.
+	// It is empty if the execution never reached the circuit.
+	CircuitState CircuitState
+}
+
+// DoResultC runs your function in a synchronous manner like DoC, and also returns
+// an ExecutionResult describing the execution once its events have been reported.
+func DoResultC(ctx context.Context, name string, run runFuncC, fallback fallbackFuncC) (*ExecutionResult, error) {
+	cmd := newCommand(ctx, run, fallback)
+
+	err := doC(ctx, name, cmd)
+	totalDuration := cmd.since(cmd.start)
+
+	// Without a circuit nothing ran, and nothing will be reported.
+	if cmd.circuit != nil {
+		<-cmd.reported
+	}
+
+	cmd.Lock()
+	defer cmd.Unlock()
+
+	return &ExecutionResult{
+		Events:        append([]string(nil), cmd.events...),
+		RunDuration:   cmd.runDuration,
+		TotalDuration: totalDuration,
+		FallbackRan:   cmd.fallbackRan,
+		FallbackErr:   cmd.fallbackErr,
+		CircuitState:  cmd.circuitState,
+	}, err
+}
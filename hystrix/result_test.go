@@ -0,0 +1,111 @@
+package hystrix
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDoResultC(t *testing.T) {
+	Convey("with a closed circuit", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		defer Flush()
+		ConfigureCommand("", CommandConfig{Timeout: 50})
+
+		Convey("a successful run is described as such", func() {
+			result, err := DoResultC(context.Background(), "", func(ctx context.Context) error {
+				fake.Advance(10 * time.Millisecond)
+				return nil
+			}, nil)
+			So(err, ShouldBeNil)
+			So(result.Events, ShouldResemble, []string{"success"})
+			So(result.RunDuration, ShouldBeGreaterThanOrEqualTo, 10*time.Millisecond)
+			So(result.TotalDuration, ShouldBeGreaterThanOrEqualTo, result.RunDuration)
+			So(result.FallbackRan, ShouldBeFalse)
+			So(result.CircuitState, ShouldEqual, CircuitClosed)
+		})
+
+		Convey("a failed run reports the fallback", func() {
+			result, err := DoResultC(context.Background(), "", func(ctx context.Context) error {
+				return fmt.Errorf("run_error")
+			}, func(ctx context.Context, err error) error {
+				return fmt.Errorf("fallback_error")
+			})
+			So(err, ShouldNotBeNil)
+			So(result.Events, ShouldResemble, []string{"failure", "fallback-failure"})
+			So(result.FallbackRan, ShouldBeTrue)
+			So(result.FallbackErr.Error(), ShouldEqual, "fallback_error")
+		})
+
+		Convey("a timed out run has no run duration", func() {
+			release := make(chan struct{})
+			defer close(release)
+			go func() {
+				fake.WaitForTimers(1)
+				fake.Advance(50 * time.Millisecond)
+			}()
+
+			result, err := DoResultC(context.Background(), "", func(ctx context.Context) error {
+				<-release
+				return nil
+			}, func(ctx context.Context, err error) error {
+				return nil
+			})
+			So(err, ShouldBeNil)
+			So(result.Events, ShouldResemble, []string{"timeout", "fallback-success"})
+			So(result.RunDuration, ShouldEqual, 0)
+			So(result.FallbackRan, ShouldBeTrue)
+			So(result.FallbackErr, ShouldBeNil)
+		})
+	})
+
+	Convey("with an open circuit", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		defer Flush()
+		ConfigureCommand("", CommandConfig{SleepWindow: 10})
+
+		cb, _, _ := GetCircuit("")
+		cb.setOpen()
+
+		Convey("a rejected execution was admitted while open", func() {
+			result, err := DoResultC(context.Background(), "", func(ctx context.Context) error {
+				return nil
+			}, nil)
+			So(err, ShouldResemble, ErrCircuitOpen)
+			So(result.Events, ShouldResemble, []string{"short-circuit"})
+			So(result.CircuitState, ShouldEqual, CircuitOpen)
+		})
+
+		Convey("an execution after the sleep window is a half open test", func() {
+			fake.Advance(20 * time.Millisecond)
+
+			result, err := DoResultC(context.Background(), "", func(ctx context.Context) error {
+				return nil
+			}, nil)
+			So(err, ShouldBeNil)
+			So(result.CircuitState, ShouldEqual, CircuitHalfOpen)
+		})
+	})
+
+	Convey("with a command isolated by semaphore", t, func() {
+		defer Flush()
+		ConfigureCommand("", CommandConfig{ExecutionIsolationStrategy: IsolationSemaphore})
+
+		Convey("the execution is described once DoResultC returns", func() {
+			result, err := DoResultC(context.Background(), "", func(ctx context.Context) error {
+				return nil
+			}, nil)
+			So(err, ShouldBeNil)
+			So(result.Events, ShouldResemble, []string{"success"})
+			So(result.CircuitState, ShouldEqual, CircuitClosed)
+		})
+	})
+}
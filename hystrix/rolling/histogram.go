@@ -0,0 +1,135 @@
+package rolling
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+const (
+	// histogramSubBucketBits sets the precision of a Histogram: every power of
+	// two is split into 2^histogramSubBucketBits equal ranges, so a duration is
+	// known to within 1/1024 of its value.
+	histogramSubBucketBits = 10
+	histogramSubBuckets    = 1 << histogramSubBucketBits
+)
+
+// Histogram counts durations in log-linear ranges, at microsecond resolution.
+// Durations below 2048µs are counted exactly and longer ones with a relative
+// error of at most 1/1024, so the memory a Histogram takes depends on the
+// spread of the durations and not on how many were recorded.
+//
+// Every Histogram uses the same ranges, so histograms from different buckets
+// or hosts can be merged, and they can be passed between hosts as JSON.
+type Histogram struct {
+	// Counts holds how many durations fell into each range, by range index.
+	Counts map[int]uint64 `json:"counts"`
+	// Count is how many durations were recorded.
+	Count uint64 `json:"count"`
+	// Sum, Min and Max are exact, so that Mean is too.
+	Sum time.Duration `json:"sum"`
+	Min time.Duration `json:"min"`
+	Max time.Duration `json:"max"`
+}
+
+// NewHistogram creates an empty Histogram.
+func NewHistogram() *Histogram {
+	return &Histogram{Counts: make(map[int]uint64)}
+}
+
+// Record counts the given duration. Negative durations are counted as zero.
+func (h *Histogram) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	if h.Counts == nil {
+		h.Counts = make(map[int]uint64)
+	}
+
+	h.Counts[histogramIndex(d)]++
+	if h.Count == 0 || d < h.Min {
+		h.Min = d
+	}
+	if d > h.Max {
+		h.Max = d
+	}
+	h.Count++
+	h.Sum += d
+}
+
+// Merge adds the durations counted by other to h.
+func (h *Histogram) Merge(other *Histogram) {
+	if other == nil || other.Count == 0 {
+		return
+	}
+	if h.Counts == nil {
+		h.Counts = make(map[int]uint64)
+	}
+
+	for i, c := range other.Counts {
+		h.Counts[i] += c
+	}
+	if h.Count == 0 || other.Min < h.Min {
+		h.Min = other.Min
+	}
+	if other.Max > h.Max {
+		h.Max = other.Max
+	}
+	h.Count += other.Count
+	h.Sum += other.Sum
+}
+
+// Percentile returns the duration at or below which the given percentage of
+// the durations fall. It is the highest duration of the range the percentile
+// fell into, limited to the durations actually recorded.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	return newTimingSnapshot(h).Percentile(p)
+}
+
+// Mean returns the average of the durations recorded.
+func (h *Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// ordinal returns the 1-based rank of the given percentile among count values.
+func ordinal(count uint64, percentile float64) int64 {
+	if percentile == 0 && count > 0 {
+		return 1
+	}
+
+	return int64(math.Ceil((percentile / float64(100)) * float64(count)))
+}
+
+// histogramIndex returns the index of the range the duration falls into.
+func histogramIndex(d time.Duration) int {
+	v := uint64(d / time.Microsecond)
+	if v < 2*histogramSubBuckets {
+		return int(v)
+	}
+
+	shift := bits.Len64(v) - histogramSubBucketBits - 1
+	return shift*histogramSubBuckets + int(v>>uint(shift))
+}
+
+// histogramLowest returns the lowest duration falling into the range at index i.
+func histogramLowest(i int) time.Duration {
+	if i < 2*histogramSubBuckets {
+		return time.Duration(i) * time.Microsecond
+	}
+
+	shift := i/histogramSubBuckets - 1
+	return time.Duration(uint64(i-shift*histogramSubBuckets)<<uint(shift)) * time.Microsecond
+}
+
+// histogramHighest returns the highest duration falling into the range at index i.
+func histogramHighest(i int) time.Duration {
+	if i < 2*histogramSubBuckets {
+		return histogramLowest(i)
+	}
+
+	shift := i/histogramSubBuckets - 1
+	return histogramLowest(i) + time.Duration((1<<uint(shift))-1)*time.Microsecond
+}
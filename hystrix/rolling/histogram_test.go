@@ -0,0 +1,97 @@
+package rolling
+
+import (
+	"encoding/json"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHistogram(t *testing.T) {
+	Convey("given a new histogram", t, func() {
+		h := NewHistogram()
+
+		Convey("Percentile() and Mean() should be 0", func() {
+			So(h.Percentile(50), ShouldEqual, 0)
+			So(h.Mean(), ShouldEqual, 0)
+		})
+
+		Convey("short durations are counted to the microsecond", func() {
+			h.Record(1500 * time.Microsecond)
+			h.Record(1501 * time.Microsecond)
+			h.Record(1502 * time.Microsecond)
+
+			So(h.Percentile(0), ShouldEqual, 1500*time.Microsecond)
+			So(h.Percentile(50), ShouldEqual, 1501*time.Microsecond)
+			So(h.Percentile(100), ShouldEqual, 1502*time.Microsecond)
+			So(h.Mean(), ShouldEqual, 1501*time.Microsecond)
+		})
+
+		Convey("after recording many random durations", func() {
+			random := rand.New(rand.NewSource(1))
+			var durations []time.Duration
+			for i := 0; i < 10000; i++ {
+				d := time.Duration(random.Int63n(int64(30 * time.Second)))
+				durations = append(durations, d)
+				h.Record(d)
+			}
+			sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+			Convey("each percentile is within the relative error of the exact one", func() {
+				for _, p := range []float64{0, 1, 25, 50, 75, 90, 99, 99.9, 100} {
+					exact := durations[ordinal(uint64(len(durations)), p)-1]
+					So(h.Percentile(p), ShouldAlmostEqual, exact, float64(exact)/histogramSubBuckets+float64(time.Microsecond))
+				}
+			})
+
+			Convey("the mean is exact", func() {
+				var sum time.Duration
+				for _, d := range durations {
+					sum += d
+				}
+				So(h.Mean(), ShouldEqual, sum/time.Duration(len(durations)))
+			})
+
+			Convey("the memory used is bounded by the spread of the durations", func() {
+				So(len(h.Counts), ShouldBeLessThan, 25*histogramSubBuckets)
+			})
+		})
+	})
+}
+
+func TestHistogramMerge(t *testing.T) {
+	Convey("given histograms recorded separately", t, func() {
+		all := NewHistogram()
+		parts := []*Histogram{NewHistogram(), NewHistogram(), NewHistogram()}
+		random := rand.New(rand.NewSource(2))
+		for i := 0; i < 3000; i++ {
+			d := time.Duration(random.Int63n(int64(5 * time.Second)))
+			all.Record(d)
+			parts[i%len(parts)].Record(d)
+		}
+
+		Convey("merging them gives the histogram of all the durations", func() {
+			merged := NewHistogram()
+			for _, p := range parts {
+				merged.Merge(p)
+			}
+			So(merged, ShouldResemble, all)
+		})
+
+		Convey("they can be merged after being passed between hosts", func() {
+			merged := NewHistogram()
+			for _, p := range parts {
+				b, err := json.Marshal(p)
+				So(err, ShouldBeNil)
+
+				var received Histogram
+				So(json.Unmarshal(b, &received), ShouldBeNil)
+				merged.Merge(&received)
+			}
+			So(merged, ShouldResemble, all)
+		})
+	})
+}
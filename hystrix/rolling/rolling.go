@@ -1,50 +1,114 @@
 package rolling
 
 import (
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
 )
 
 // Number tracks a numberBucket over a bounded number of
-// time buckets. Currently the buckets are one second long and only the last 10 seconds are kept.
+// time buckets. Unless given a Window, the buckets are one second long and only the last 10 seconds are kept.
+//
+// The buckets are kept in a fixed ring, and values are added to them atomically,
+// so that updates don't wait on each other or on readers.
 type Number struct {
-	Buckets map[int64]*numberBucket
+	// Buckets and Mutex used to hold the values of the buckets and guard them.
+	//
+	// Deprecated: Number keeps its buckets in a ring instead, and leaves Buckets
+	// empty. Use Sum, Max or Avg to read its values.
+	Buckets map[int64]*deprecatedNumberBucket
 	Mutex   *sync.RWMutex
+
+	// buckets holds a *numberBucket for each of the window's buckets, and one
+	// more for the bucket which is leaving the window.
+	buckets []atomic.Value
+	// rollMutex is held while a bucket in the ring is replaced.
+	rollMutex *sync.Mutex
+
+	clock  clock.Clock
+	window Window
 }
 
-type numberBucket struct {
+type deprecatedNumberBucket struct {
 	Value float64
 }
 
-// NewNumber initializes a RollingNumber struct.
+type numberBucket struct {
+	key int64
+	// value holds the bits of a float64, and is only accessed atomically.
+	value uint64
+}
+
+// NewNumber initializes a RollingNumber struct using the default clock.
 func NewNumber() *Number {
+	return NewNumberWithClock(clock.Default())
+}
+
+// NewNumberWithClock initializes a RollingNumber struct whose buckets follow the given clock.
+func NewNumberWithClock(c clock.Clock) *Number {
+	return NewNumberWithWindow(DefaultNumberWindow, c)
+}
+
+// NewNumberWithWindow initializes a RollingNumber struct covering the given window,
+// whose buckets follow the given clock. An empty window means DefaultNumberWindow.
+func NewNumberWithWindow(w Window, c clock.Clock) *Number {
 	r := &Number{
-		Buckets: make(map[int64]*numberBucket),
-		Mutex:   &sync.RWMutex{},
+		Buckets:   make(map[int64]*deprecatedNumberBucket),
+		Mutex:     &sync.RWMutex{},
+		rollMutex: &sync.Mutex{},
+		clock:     c,
+		window:    w.orDefault(DefaultNumberWindow),
 	}
+	r.buckets = make([]atomic.Value, r.window.Buckets+1)
 	return r
 }
 
-func (r *Number) getCurrentBucket() *numberBucket {
-	now := time.Now().Unix()
-	var bucket *numberBucket
-	var ok bool
+// Window returns the window the number covers.
+func (r *Number) Window() Window {
+	return r.window
+}
+
+func (r *Number) now() time.Time {
+	if r.clock == nil {
+		return time.Now()
+	}
+	return r.clock.Now()
+}
 
-	if bucket, ok = r.Buckets[now]; !ok {
-		bucket = &numberBucket{}
-		r.Buckets[now] = bucket
+func (r *Number) getCurrentBucket() *numberBucket {
+	now := r.window.bucket(r.now())
+	slot := &r.buckets[r.slot(now)]
+	if b, ok := slot.Load().(*numberBucket); ok && b.key == now {
+		return b
 	}
 
-	return bucket
+	// the slot still holds a bucket which has left the window, so replace it
+	// unless another update already has.
+	r.rollMutex.Lock()
+	defer r.rollMutex.Unlock()
+
+	b, ok := slot.Load().(*numberBucket)
+	if !ok || b.key != now {
+		b = &numberBucket{key: now}
+		slot.Store(b)
+	}
+	return b
 }
 
-func (r *Number) removeOldBuckets() {
-	now := time.Now().Unix() - 10
+// slot returns the index of the bucket with the given key in the ring.
+func (r *Number) slot(key int64) int {
+	n := int64(len(r.buckets))
+	return int(((key % n) + n) % n)
+}
 
-	for timestamp := range r.Buckets {
-		// TODO: configurable rolling window
-		if timestamp <= now {
-			delete(r.Buckets, timestamp)
+// bucketsSince calls f with the value of each bucket no older than oldest.
+func (r *Number) bucketsSince(oldest int64, f func(value float64)) {
+	for i := range r.buckets {
+		if b, ok := r.buckets[i].Load().(*numberBucket); ok && b.key >= oldest {
+			f(math.Float64frombits(atomic.LoadUint64(&b.value)))
 		}
 	}
 }
@@ -55,62 +119,49 @@ func (r *Number) Increment(i float64) {
 		return
 	}
 
-	r.Mutex.Lock()
-	defer r.Mutex.Unlock()
-
 	b := r.getCurrentBucket()
-	b.Value += i
-	r.removeOldBuckets()
+	for {
+		old := atomic.LoadUint64(&b.value)
+		if atomic.CompareAndSwapUint64(&b.value, old, math.Float64bits(math.Float64frombits(old)+i)) {
+			return
+		}
+	}
 }
 
 // UpdateMax updates the maximum value in the current bucket.
 func (r *Number) UpdateMax(n float64) {
-	r.Mutex.Lock()
-	defer r.Mutex.Unlock()
-
 	b := r.getCurrentBucket()
-	if n > b.Value {
-		b.Value = n
+	for {
+		old := atomic.LoadUint64(&b.value)
+		if n <= math.Float64frombits(old) || atomic.CompareAndSwapUint64(&b.value, old, math.Float64bits(n)) {
+			return
+		}
 	}
-	r.removeOldBuckets()
 }
 
-// Sum sums the values over the buckets in the last 10 seconds.
+// Sum sums the values over the buckets in the window.
 func (r *Number) Sum(now time.Time) float64 {
 	sum := float64(0)
-
-	r.Mutex.RLock()
-	defer r.Mutex.RUnlock()
-
-	for timestamp, bucket := range r.Buckets {
-		// TODO: configurable rolling window
-		if timestamp >= now.Unix()-10 {
-			sum += bucket.Value
-		}
-	}
+	r.bucketsSince(r.window.oldest(now), func(value float64) {
+		sum += value
+	})
 
 	return sum
 }
 
-// Max returns the maximum value seen in the last 10 seconds.
+// Max returns the maximum value seen in the window.
 func (r *Number) Max(now time.Time) float64 {
 	var max float64
-
-	r.Mutex.RLock()
-	defer r.Mutex.RUnlock()
-
-	for timestamp, bucket := range r.Buckets {
-		// TODO: configurable rolling window
-		if timestamp >= now.Unix()-10 {
-			if bucket.Value > max {
-				max = bucket.Value
-			}
+	r.bucketsSince(r.window.oldest(now), func(value float64) {
+		if value > max {
+			max = value
 		}
-	}
+	})
 
 	return max
 }
 
+// Avg returns the average value per second over the window.
 func (r *Number) Avg(now time.Time) float64 {
-	return r.Sum(now) / 10
+	return r.Sum(now) / r.window.Length.Seconds()
 }
@@ -1,37 +1,41 @@
 package rolling
 
 import (
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/afex/hystrix-go/hystrix/clock"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
 func TestMax(t *testing.T) {
 
 	Convey("when adding values to a rolling number", t, func() {
-		n := NewNumber()
+		c := clock.NewFake(time.Now())
+		n := NewNumberWithClock(c)
 		for _, x := range []float64{10, 11, 9} {
 			n.UpdateMax(x)
-			time.Sleep(1 * time.Second)
+			c.Advance(1 * time.Second)
 		}
 
 		Convey("it should know the maximum", func() {
-			So(n.Max(time.Now()), ShouldEqual, 11)
+			So(n.Max(c.Now()), ShouldEqual, 11)
 		})
 	})
 }
 
 func TestAvg(t *testing.T) {
 	Convey("when adding values to a rolling number", t, func() {
-		n := NewNumber()
+		c := clock.NewFake(time.Now())
+		n := NewNumberWithClock(c)
 		for _, x := range []float64{0.5, 1.5, 2.5, 3.5, 4.5} {
 			n.Increment(x)
-			time.Sleep(1 * time.Second)
+			c.Advance(1 * time.Second)
 		}
 
 		Convey("it should calculate the average over the number of configured buckets", func() {
-			So(n.Avg(time.Now()), ShouldEqual, 1.25)
+			So(n.Avg(c.Now()), ShouldEqual, 1.25)
 		})
 	})
 }
@@ -55,3 +59,125 @@ func BenchmarkRollingNumberUpdateMax(b *testing.B) {
 		n.UpdateMax(float64(i))
 	}
 }
+
+func BenchmarkRollingNumberIncrementParallel(b *testing.B) {
+	n := NewNumber()
+
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			n.Increment(1)
+		}
+	})
+}
+
+func BenchmarkRollingNumberUpdateMaxParallel(b *testing.B) {
+	n := NewNumber()
+
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		var i float64
+		for pb.Next() {
+			i++
+			n.UpdateMax(i)
+		}
+	})
+}
+
+func BenchmarkRollingNumberSumParallel(b *testing.B) {
+	n := NewNumber()
+	c := clock.Real()
+
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			// every 8th operation is a read, as when a circuit checks its health.
+			i++
+			if i%8 == 0 {
+				n.Sum(c.Now())
+			} else {
+				n.Increment(1)
+			}
+		}
+	})
+}
+
+func TestConcurrentIncrements(t *testing.T) {
+	Convey("when many goroutines increment a rolling number at once", t, func() {
+		c := clock.NewFake(time.Now())
+		n := NewNumberWithClock(c)
+		max := NewNumberWithClock(c)
+
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					n.Increment(1)
+					max.UpdateMax(float64(i))
+				}
+			}()
+		}
+		wg.Wait()
+
+		Convey("no update is lost", func() {
+			So(n.Sum(c.Now()), ShouldEqual, 8000)
+			So(max.Max(c.Now()), ShouldEqual, 999)
+		})
+	})
+}
+
+func TestRollingWindow(t *testing.T) {
+	Convey("when a value was added to a rolling number", t, func() {
+		c := clock.NewFake(time.Now())
+		n := NewNumberWithClock(c)
+		n.Increment(1)
+
+		Convey("it is counted for 10 seconds", func() {
+			c.Advance(10 * time.Second)
+			So(n.Sum(c.Now()), ShouldEqual, 1)
+
+			Convey("and then dropped", func() {
+				c.Advance(1 * time.Second)
+				So(n.Sum(c.Now()), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestNumberWindow(t *testing.T) {
+	Convey("when a value was added to a number with a 2 second window in 4 buckets", t, func() {
+		c := clock.NewFake(time.Now())
+		n := NewNumberWithWindow(Window{Length: 2 * time.Second, Buckets: 4}, c)
+		n.Increment(3)
+
+		Convey("it is counted for 2 seconds", func() {
+			c.Advance(2 * time.Second)
+			So(n.Sum(c.Now()), ShouldEqual, 3)
+			So(n.Max(c.Now()), ShouldEqual, 3)
+
+			Convey("and then dropped with its bucket", func() {
+				c.Advance(500 * time.Millisecond)
+				So(n.Sum(c.Now()), ShouldEqual, 0)
+				So(n.Max(c.Now()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("the average is taken over the window", func() {
+			So(n.Avg(c.Now()), ShouldEqual, 1.5)
+		})
+	})
+
+	Convey("when a number is given a window without buckets", t, func() {
+		n := NewNumberWithWindow(Window{Length: time.Second}, clock.Real())
+
+		Convey("it uses the default window", func() {
+			So(n.Window(), ShouldResemble, DefaultNumberWindow)
+		})
+	})
+}
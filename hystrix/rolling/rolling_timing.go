@@ -1,148 +1,161 @@
 package rolling
 
 import (
-	"math"
-	"sort"
 	"sync"
 	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
 )
 
-// Timing maintains time Durations for each time bucket.
-// The Durations are kept in an array to allow for a variety of
-// statistics to be calculated from the source data.
+// Timing maintains a Histogram of time Durations for each time bucket.
+// The histograms take bounded memory however many durations are added,
+// and are merged to calculate statistics over the window.
 type Timing struct {
-	Buckets map[int64]*timingBucket
+	// Buckets holds a Histogram of the durations added in each bucket.
+	//
+	// Deprecated: the buckets no longer hold the durations themselves. Use
+	// Histogram or Snapshot for statistics over the window.
+	Buckets map[int64]*Histogram
 	Mutex   *sync.RWMutex
 
+	// CachedSortedDurations and LastCachedTime hold the result of the last
+	// call to SortedDurations, and when it was made.
+	//
+	// Deprecated: use Snapshot instead of SortedDurations.
 	CachedSortedDurations []time.Duration
 	LastCachedTime        int64
-}
 
-type timingBucket struct {
-	Durations []time.Duration
+	clock  clock.Clock
+	window Window
 }
 
-// NewTiming creates a RollingTiming struct.
+// NewTiming creates a RollingTiming struct using the default clock.
 func NewTiming() *Timing {
+	return NewTimingWithClock(clock.Default())
+}
+
+// NewTimingWithClock creates a RollingTiming struct whose buckets follow the given clock.
+func NewTimingWithClock(c clock.Clock) *Timing {
+	return NewTimingWithWindow(DefaultTimingWindow, c)
+}
+
+// NewTimingWithWindow creates a RollingTiming struct covering the given window,
+// whose buckets follow the given clock. An empty window means DefaultTimingWindow.
+func NewTimingWithWindow(w Window, c clock.Clock) *Timing {
 	r := &Timing{
-		Buckets: make(map[int64]*timingBucket),
+		Buckets: make(map[int64]*Histogram),
 		Mutex:   &sync.RWMutex{},
+		clock:   c,
+		window:  w.orDefault(DefaultTimingWindow),
 	}
 	return r
 }
 
-type byDuration []time.Duration
+// Window returns the window the timing covers.
+func (r *Timing) Window() Window {
+	return r.window
+}
 
-func (c byDuration) Len() int           { return len(c) }
-func (c byDuration) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
-func (c byDuration) Less(i, j int) bool { return c[i] < c[j] }
+func (r *Timing) now() time.Time {
+	if r.clock == nil {
+		return time.Now()
+	}
+	return r.clock.Now()
+}
 
-// SortedDurations returns an array of time.Duration sorted from shortest
-// to longest that have occurred in the last 60 seconds.
-func (r *Timing) SortedDurations() []time.Duration {
+// Histogram returns the durations that have occurred in the window, merged
+// into a single Histogram which the caller may keep or merge with others.
+func (r *Timing) Histogram() *Histogram {
 	r.Mutex.RLock()
-	t := r.LastCachedTime
+	defer r.Mutex.RUnlock()
+
+	return r.histogram(r.now())
+}
+
+// Snapshot returns statistics of the durations in the window at now, all
+// calculated from the same set of durations.
+func (r *Timing) Snapshot(now time.Time) *TimingSnapshot {
+	r.Mutex.RLock()
+	h := r.histogram(now)
 	r.Mutex.RUnlock()
 
-	if t+time.Duration(1*time.Second).Nanoseconds() > time.Now().UnixNano() {
-		// don't recalculate if current cache is still fresh
-		return r.CachedSortedDurations
+	return newTimingSnapshot(h)
+}
+
+// histogram merges the buckets in the window at now. The caller must hold Mutex.
+func (r *Timing) histogram(now time.Time) *Histogram {
+	h := NewHistogram()
+	oldest := r.window.oldest(now)
+	for timestamp, b := range r.Buckets {
+		if timestamp >= oldest {
+			h.Merge(b)
+		}
 	}
+	return h
+}
 
-	var durations byDuration
-	now := time.Now()
+// SortedDurations returns the durations that have occurred in the window,
+// sorted from shortest to longest, as they are counted by its Histogram. The
+// result is recalculated at most once a second.
+//
+// Deprecated: use Snapshot, which calculates statistics without listing every
+// duration.
+func (r *Timing) SortedDurations() []time.Duration {
+	now := r.now()
 
 	r.Mutex.Lock()
 	defer r.Mutex.Unlock()
 
-	for timestamp, b := range r.Buckets {
-		// TODO: configurable rolling window
-		if timestamp >= now.Unix()-60 {
-			for _, d := range b.Durations {
-				durations = append(durations, d)
-			}
-		}
+	if r.LastCachedTime+time.Second.Nanoseconds() > now.UnixNano() {
+		// don't recalculate if current cache is still fresh
+		return r.CachedSortedDurations
 	}
 
-	sort.Sort(durations)
-
-	r.CachedSortedDurations = durations
-	r.LastCachedTime = time.Now().UnixNano()
+	r.CachedSortedDurations = newTimingSnapshot(r.histogram(now)).durations()
+	r.LastCachedTime = now.UnixNano()
 
 	return r.CachedSortedDurations
 }
 
-func (r *Timing) getCurrentBucket() *timingBucket {
-	r.Mutex.RLock()
-	now := time.Now()
-	bucket, exists := r.Buckets[now.Unix()]
-	r.Mutex.RUnlock()
-
+func (r *Timing) getCurrentBucket() *Histogram {
+	now := r.window.bucket(r.now())
+	bucket, exists := r.Buckets[now]
 	if !exists {
-		r.Mutex.Lock()
-		defer r.Mutex.Unlock()
-
-		r.Buckets[now.Unix()] = &timingBucket{}
-		bucket = r.Buckets[now.Unix()]
+		bucket = NewHistogram()
+		r.Buckets[now] = bucket
 	}
 
 	return bucket
 }
 
 func (r *Timing) removeOldBuckets() {
-	now := time.Now()
+	oldest := r.window.oldest(r.now())
 
 	for timestamp := range r.Buckets {
-		// TODO: configurable rolling window
-		if timestamp <= now.Unix()-60 {
+		if timestamp < oldest {
 			delete(r.Buckets, timestamp)
 		}
 	}
 }
 
-// Add appends the time.Duration given to the current time bucket.
+// Add records the time.Duration given in the current time bucket.
 func (r *Timing) Add(duration time.Duration) {
-	b := r.getCurrentBucket()
-
 	r.Mutex.Lock()
 	defer r.Mutex.Unlock()
 
-	b.Durations = append(b.Durations, duration)
+	r.getCurrentBucket().Record(duration)
 	r.removeOldBuckets()
 }
 
-// Percentile computes the percentile given with a linear interpolation.
+// Percentile computes the given percentile of the durations in the window, in
+// milliseconds. Use Snapshot for microsecond resolution, or to calculate more
+// than one statistic.
 func (r *Timing) Percentile(p float64) uint32 {
-	sortedDurations := r.SortedDurations()
-	length := len(sortedDurations)
-	if length <= 0 {
-		return 0
-	}
-
-	pos := r.ordinal(len(sortedDurations), p) - 1
-	return uint32(sortedDurations[pos].Nanoseconds() / 1000000)
-}
-
-func (r *Timing) ordinal(length int, percentile float64) int64 {
-	if percentile == 0 && length > 0 {
-		return 1
-	}
-
-	return int64(math.Ceil((percentile / float64(100)) * float64(length)))
+	return uint32(r.Snapshot(r.now()).Percentile(p) / time.Millisecond)
 }
 
-// Mean computes the average timing in the last 60 seconds.
+// Mean computes the average timing in the window, in milliseconds. Use
+// Snapshot for microsecond resolution, or to calculate more than one statistic.
 func (r *Timing) Mean() uint32 {
-	sortedDurations := r.SortedDurations()
-	var sum time.Duration
-	for _, d := range sortedDurations {
-		sum += d
-	}
-
-	length := int64(len(sortedDurations))
-	if length == 0 {
-		return 0
-	}
-
-	return uint32(sum.Nanoseconds()/length) / 1000000
+	return uint32(r.Snapshot(r.now()).Mean / time.Millisecond)
 }
@@ -4,13 +4,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/afex/hystrix-go/hystrix/clock"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
 func TestOrdinal(t *testing.T) {
 	Convey("given a new rolling timing", t, func() {
 
-		r := NewTiming()
+		c := clock.NewFake(time.Now())
+		r := NewTimingWithClock(c)
 
 		Convey("Mean() should be 0", func() {
 			So(r.Mean(), ShouldEqual, 0)
@@ -18,7 +20,7 @@ func TestOrdinal(t *testing.T) {
 
 		Convey("and given a set of lengths and percentiles", func() {
 			var ordinalTests = []struct {
-				length   int
+				length   uint64
 				perc     float64
 				expected int64
 			}{
@@ -38,14 +40,14 @@ func TestOrdinal(t *testing.T) {
 			Convey("each should generate the expected ordinal", func() {
 
 				for _, s := range ordinalTests {
-					So(r.ordinal(s.length, s.perc), ShouldEqual, s.expected)
+					So(ordinal(s.length, s.perc), ShouldEqual, s.expected)
 				}
 			})
 		})
 
 		Convey("after adding 2 timings", func() {
 			r.Add(100 * time.Millisecond)
-			time.Sleep(2 * time.Second)
+			c.Advance(2 * time.Second)
 			r.Add(200 * time.Millisecond)
 
 			Convey("the mean should be the average of the timings", func() {
@@ -68,3 +70,66 @@ func TestOrdinal(t *testing.T) {
 		})
 	})
 }
+
+func TestTimingHistogram(t *testing.T) {
+	Convey("when sub-millisecond durations were added to a timing", t, func() {
+		c := clock.NewFake(time.Now())
+		r := NewTimingWithClock(c)
+		r.Add(250 * time.Microsecond)
+		r.Add(750 * time.Microsecond)
+
+		Convey("its histogram tells them apart", func() {
+			h := r.Histogram()
+			So(h.Count, ShouldEqual, 2)
+			So(h.Percentile(50), ShouldEqual, 250*time.Microsecond)
+			So(h.Mean(), ShouldEqual, 500*time.Microsecond)
+		})
+	})
+}
+
+func TestTimingWindow(t *testing.T) {
+	Convey("when a duration was added to a timing with a 5 second window", t, func() {
+		c := clock.NewFake(time.Now())
+		r := NewTimingWithWindow(Window{Length: 5 * time.Second, Buckets: 5}, c)
+		r.Add(100 * time.Millisecond)
+
+		Convey("it is counted within the window", func() {
+			c.Advance(5 * time.Second)
+			So(r.Mean(), ShouldEqual, 100)
+
+			Convey("and then dropped", func() {
+				c.Advance(1 * time.Second)
+				So(r.Mean(), ShouldEqual, 0)
+			})
+
+			Convey("and kept by adding another duration", func() {
+				r.Add(300 * time.Millisecond)
+				So(r.Mean(), ShouldEqual, 200)
+			})
+		})
+	})
+}
+
+func TestSortedDurations(t *testing.T) {
+	Convey("when durations were added to a timing", t, func() {
+		c := clock.NewFake(time.Now())
+		r := NewTimingWithClock(c)
+		r.Add(3 * time.Millisecond)
+		r.Add(1 * time.Millisecond)
+		r.Add(2 * time.Millisecond)
+
+		Convey("they are listed from shortest to longest", func() {
+			So(r.SortedDurations(), ShouldResemble, []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond})
+			So(r.CachedSortedDurations, ShouldResemble, r.SortedDurations())
+		})
+
+		Convey("the list is recalculated at most once a second", func() {
+			r.SortedDurations()
+			r.Add(4 * time.Millisecond)
+			So(len(r.SortedDurations()), ShouldEqual, 3)
+
+			c.Advance(time.Second)
+			So(len(r.SortedDurations()), ShouldEqual, 4)
+		})
+	})
+}
@@ -0,0 +1,103 @@
+package rolling
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// TimingSnapshot holds statistics of the durations in a Timing's window, all
+// taken at the same moment. It is never modified after it is created, so it can
+// be shared freely.
+type TimingSnapshot struct {
+	Count  uint64
+	Min    time.Duration
+	Max    time.Duration
+	Mean   time.Duration
+	StdDev time.Duration
+
+	// indexes are the histogram ranges holding any durations, in ascending
+	// order, and cumulative the number of durations up to each of them.
+	indexes    []int
+	cumulative []uint64
+}
+
+// newTimingSnapshot calculates the statistics of the durations counted by h.
+func newTimingSnapshot(h *Histogram) *TimingSnapshot {
+	s := &TimingSnapshot{
+		Count: h.Count,
+		Min:   h.Min,
+		Max:   h.Max,
+		Mean:  h.Mean(),
+	}
+	if h.Count == 0 {
+		return s
+	}
+
+	s.indexes = make([]int, 0, len(h.Counts))
+	for i := range h.Counts {
+		s.indexes = append(s.indexes, i)
+	}
+	sort.Ints(s.indexes)
+
+	s.cumulative = make([]uint64, len(s.indexes))
+	var seen uint64
+	var variance float64
+	for n, i := range s.indexes {
+		c := h.Counts[i]
+		seen += c
+		s.cumulative[n] = seen
+
+		// each range stands for the duration at its middle.
+		mid := float64(histogramLowest(i)+histogramHighest(i)) / 2
+		variance += float64(c) * math.Pow(mid-float64(s.Mean), 2)
+	}
+	s.StdDev = time.Duration(math.Sqrt(variance / float64(h.Count)))
+
+	return s
+}
+
+// Percentile returns the duration at or below which the given percentage of
+// the durations fall, with the precision of a Histogram.
+func (s *TimingSnapshot) Percentile(p float64) time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+
+	rank := uint64(ordinal(s.Count, p))
+	n := sort.Search(len(s.cumulative), func(n int) bool {
+		return s.cumulative[n] >= rank
+	})
+	if n == len(s.cumulative) {
+		return s.Max
+	}
+
+	d := histogramHighest(s.indexes[n])
+	if d < s.Min {
+		return s.Min
+	}
+	if d > s.Max {
+		return s.Max
+	}
+	return d
+}
+
+// durations lists the durations counted, in ascending order, each standing
+// for the highest duration of its range within Min and Max.
+func (s *TimingSnapshot) durations() []time.Duration {
+	var durations []time.Duration
+	var seen uint64
+	for n, i := range s.indexes {
+		d := histogramHighest(i)
+		if d < s.Min {
+			d = s.Min
+		}
+		if d > s.Max {
+			d = s.Max
+		}
+		for ; seen < s.cumulative[n]; seen++ {
+			durations = append(durations, d)
+		}
+	}
+	return durations
+}
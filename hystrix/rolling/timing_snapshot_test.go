@@ -0,0 +1,54 @@
+package rolling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTimingSnapshot(t *testing.T) {
+	Convey("given a rolling timing", t, func() {
+		c := clock.NewFake(time.Now())
+		r := NewTimingWithClock(c)
+
+		Convey("a snapshot of no durations is empty", func() {
+			s := r.Snapshot(c.Now())
+			So(s.Count, ShouldEqual, 0)
+			So(s.Mean, ShouldEqual, 0)
+			So(s.StdDev, ShouldEqual, 0)
+			So(s.Percentile(99), ShouldEqual, 0)
+		})
+
+		Convey("after adding some timings", func() {
+			for _, d := range []int{2, 4, 4, 4, 5, 5, 7, 9} {
+				r.Add(time.Duration(d) * time.Millisecond)
+			}
+			s := r.Snapshot(c.Now())
+
+			Convey("the snapshot describes all of them", func() {
+				So(s.Count, ShouldEqual, 8)
+				So(s.Min, ShouldEqual, 2*time.Millisecond)
+				So(s.Max, ShouldEqual, 9*time.Millisecond)
+				So(s.Mean, ShouldEqual, 5*time.Millisecond)
+				So(s.StdDev, ShouldAlmostEqual, 2*time.Millisecond, float64(10*time.Microsecond))
+				So(s.Percentile(50), ShouldAlmostEqual, 4*time.Millisecond, float64(10*time.Microsecond))
+				So(s.Percentile(75), ShouldAlmostEqual, 5*time.Millisecond, float64(10*time.Microsecond))
+				So(s.Percentile(100), ShouldEqual, 9*time.Millisecond)
+			})
+
+			Convey("it doesn't change when more timings are added", func() {
+				r.Add(time.Second)
+				So(s.Count, ShouldEqual, 8)
+				So(s.Percentile(100), ShouldEqual, 9*time.Millisecond)
+				So(r.Snapshot(c.Now()).Percentile(100), ShouldEqual, time.Second)
+			})
+
+			Convey("a snapshot after the window has passed is empty", func() {
+				c.Advance(61 * time.Second)
+				So(r.Snapshot(c.Now()).Count, ShouldEqual, 0)
+			})
+		})
+	})
+}
@@ -0,0 +1,43 @@
+package rolling
+
+import "time"
+
+// Window describes how far back a rolling statistic looks. The window is split
+// into buckets, and values leave the statistic a whole bucket at a time.
+type Window struct {
+	// Length is how far back the statistic looks.
+	Length time.Duration
+	// Buckets is how many buckets Length is split into. Length should divide
+	// evenly by it.
+	Buckets int
+}
+
+var (
+	// DefaultNumberWindow is the window of a Number: 10 seconds in buckets of a second.
+	DefaultNumberWindow = Window{Length: 10 * time.Second, Buckets: 10}
+	// DefaultTimingWindow is the window of a Timing: 60 seconds in buckets of a second.
+	DefaultTimingWindow = Window{Length: 60 * time.Second, Buckets: 60}
+)
+
+// orDefault returns the window, or def if the window has no buckets of any length.
+func (w Window) orDefault(def Window) Window {
+	if w.Buckets <= 0 || w.Length/time.Duration(w.Buckets) <= 0 {
+		return def
+	}
+	return w
+}
+
+// bucketWidth returns the length of each bucket.
+func (w Window) bucketWidth() time.Duration {
+	return w.Length / time.Duration(w.Buckets)
+}
+
+// bucket returns the key of the bucket which t falls in.
+func (w Window) bucket(t time.Time) int64 {
+	return t.UnixNano() / int64(w.bucketWidth())
+}
+
+// oldest returns the key of the oldest bucket still in the window at now.
+func (w Window) oldest(now time.Time) int64 {
+	return w.bucket(now) - int64(w.Buckets)
+}
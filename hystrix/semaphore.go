@@ -0,0 +1,77 @@
+package hystrix
+
+import (
+	"context"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+)
+
+// doSemaphoreC runs the command on the calling goroutine, using the pool's
+// tickets as a semaphore. Without a second goroutine to abandon run, the
+// timeout is only enforced by cancelling the context passed to it, so run must
+// honor that context for the timeout to take effect.
+func doSemaphoreC(ctx context.Context, name string, cmd *command) error {
+	circuit, err := acquireCircuit(name)
+	if err != nil {
+		return err
+	}
+	cmd.circuit = circuit
+	ctx = cmd.startTrace(ctx, name)
+
+	if err := cmd.admit(ctx); err != nil {
+		cmd.releaseTicket()
+		cmd.errorWithFallback(ctx, err)
+		cmd.reportAllEvent()
+		return cmd.result()
+	}
+
+	settings := getSettings(name)
+	runCtx, cancel := ctx, context.CancelFunc(func() {})
+	if !settings.ShadowMode {
+		runCtx, cancel = clock.WithTimeout(ctx, circuit.clock, settings.Timeout)
+	}
+	defer cancel()
+
+	runStart := circuit.clock.Now()
+	traceCtx, endRun := cmd.startRunTrace(runCtx)
+	runErr := cmd.injectFaults(traceCtx)
+	if runErr == nil {
+		runErr = cmd.run(traceCtx)
+	}
+	endRun(runErr)
+	cmd.runStart = runStart
+	cmd.runDuration = cmd.since(runStart)
+	cmd.releaseTicket()
+
+	if settings.ShadowMode && cmd.runDuration >= settings.Timeout {
+		cmd.recordShadowEvent(ErrTimeout)
+	}
+
+	// A result which arrives after the deadline is discarded, just as it
+	// would be with IsolationThread.
+	if runCtx.Err() != nil {
+		runErr = ctx.Err()
+		if runErr == nil {
+			runErr = ErrTimeout
+		}
+	}
+
+	if runErr != nil {
+		cmd.errorWithFallback(ctx, runErr)
+	} else {
+		cmd.reportEvent("success")
+	}
+	cmd.reportAllEvent()
+
+	return cmd.result()
+}
+
+// result returns the error the command finished with, if any.
+func (c *command) result() error {
+	select {
+	case err := <-c.errChan:
+		return err
+	default:
+		return nil
+	}
+}
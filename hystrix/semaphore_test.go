@@ -0,0 +1,91 @@
+package hystrix
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+	"github.com/afex/hystrix-go/hystrix/metric_collector"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSemaphoreIsolation(t *testing.T) {
+	Convey("with a command isolated by semaphore", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		defer Flush()
+		ConfigureCommand("", CommandConfig{ExecutionIsolationStrategy: IsolationSemaphore, Timeout: 10, MaxConcurrentRequests: 1})
+
+		Convey("a successful run returns no error", func() {
+			ran := false
+			err := DoC(context.Background(), "", func(ctx context.Context) error {
+				// no synchronization needed, run happens on this goroutine
+				ran = true
+				return nil
+			}, nil)
+			So(err, ShouldBeNil)
+			So(ran, ShouldBeTrue)
+
+			Convey("and the ticket is returned", func() {
+				cb, _, _ := GetCircuit("")
+				So(cb.executorPool.ActiveCount(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("a failed run triggers the fallback", func() {
+			err := DoC(context.Background(), "", func(ctx context.Context) error {
+				return fmt.Errorf("run_error")
+			}, func(ctx context.Context, err error) error {
+				return fmt.Errorf("fallback_error")
+			})
+			So(err.Error(), ShouldEqual, "fallback failed with 'fallback_error'. run error was 'run_error'")
+		})
+
+		Convey("the timeout cancels the context passed to run", func() {
+			recorder := resultRecorder{results: make(chan metricCollector.MetricResult, 1)}
+			cb, _, _ := GetCircuit("")
+			cb.metrics.addCollector(recorder)
+
+			errChan := make(chan error)
+			go func() {
+				errChan <- DoC(context.Background(), "", func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				}, nil)
+			}()
+
+			fake.WaitForTimers(1)
+			fake.Advance(10 * time.Millisecond)
+			So(<-errChan, ShouldResemble, ErrTimeout)
+
+			Convey("and is recorded", func() {
+				So((<-recorder.results).Timeouts, ShouldEqual, 1)
+			})
+		})
+
+		Convey("a run which ignores its context is waited for, but still times out", func() {
+			finished := false
+			err := DoC(context.Background(), "", func(ctx context.Context) error {
+				fake.Advance(50 * time.Millisecond)
+				finished = true
+				return nil
+			}, nil)
+			So(err, ShouldResemble, ErrTimeout)
+			So(finished, ShouldBeTrue)
+		})
+
+		Convey("a command is rejected when the semaphore is exhausted", func() {
+			cb, _, _ := GetCircuit("")
+			ticket := <-cb.executorPool.Tickets
+			defer cb.executorPool.Return(ticket)
+
+			err := DoC(context.Background(), "", func(ctx context.Context) error {
+				return nil
+			}, nil)
+			So(err, ShouldResemble, ErrMaxConcurrency)
+		})
+	})
+}
@@ -16,16 +16,64 @@ var (
 	DefaultSleepWindow = 5000
 	// DefaultErrorPercentThreshold causes circuits to open once the rolling measure of errors exceeds this percent of requests
 	DefaultErrorPercentThreshold = 50
+	// DefaultIsolationStrategy is how commands are isolated from their callers
+	DefaultIsolationStrategy = IsolationThread
 	// DefaultLogger is the default logger that will be used in the Hystrix package. By default prints nothing.
 	DefaultLogger = NoopLogger{}
+	// DefaultMetricsRollingStatisticalWindow is how long, in milliseconds, the counts behind a circuit's health are kept
+	DefaultMetricsRollingStatisticalWindow = 10000
+	// DefaultMetricsRollingStatisticalWindowBuckets is how many buckets the statistical window is split into
+	DefaultMetricsRollingStatisticalWindowBuckets = 10
+	// DefaultMetricsRollingPercentileWindow is how long, in milliseconds, durations are kept for percentiles
+	DefaultMetricsRollingPercentileWindow = 60000
+	// DefaultMetricsRollingPercentileWindowBuckets is how many buckets the percentile window is split into
+	DefaultMetricsRollingPercentileWindowBuckets = 60
+	// DefaultMetricsBufferSize is how many executions may wait to be added to a circuit's metrics
+	DefaultMetricsBufferSize = 2000
+	// DefaultMetricsDropPolicy is what happens to executions reported while the metrics buffer is full
+	DefaultMetricsDropPolicy = MetricsDrop
+)
+
+const (
+	// IsolationThread runs every command on its own goroutine, with a second goroutine enforcing the timeout.
+	IsolationThread = "THREAD"
+	// IsolationSemaphore runs commands passed to Do and DoC on the caller's goroutine, enforcing the timeout
+	// only by cancelling the context passed to run. Go and GoC always use IsolationThread.
+	IsolationSemaphore = "SEMAPHORE"
+
+	// MetricsBlock makes executions wait to report their metrics until the buffer has room.
+	MetricsBlock = "BLOCK"
+	// MetricsDrop leaves executions out of the metrics, counting them in MetricResult.DroppedUpdates.
+	MetricsDrop = "DROP"
+	// MetricsAggregate adds the counts of executions to a single result which is
+	// processed once the buffer has room. Their durations are not recorded.
+	MetricsAggregate = "AGGREGATE"
 )
 
 type Settings struct {
-	Timeout                time.Duration
-	MaxConcurrentRequests  int
-	RequestVolumeThreshold uint64
-	SleepWindow            time.Duration
-	ErrorPercentThreshold  int
+	Timeout                     time.Duration
+	MaxConcurrentRequests       int
+	RequestVolumeThreshold      uint64
+	SleepWindow                 time.Duration
+	ErrorPercentThreshold       int
+	RequestsPerSecond           int
+	Burst                       int
+	MaxConcurrentRequestsPerKey int
+	PriorityReservePercent      int
+	DeadlineAdmissionPercentile int
+	ExecutionIsolationStrategy  string
+	StreamFirstItemTimeout      time.Duration
+	StreamItemTimeout           time.Duration
+	ShadowMode                  bool
+	SlowStartWindow             time.Duration
+	SlowStartRamp               string
+	MetricsBufferSize           int
+	MetricsDropPolicy           string
+
+	MetricsRollingStatisticalWindow        time.Duration
+	MetricsRollingStatisticalWindowBuckets int
+	MetricsRollingPercentileWindow         time.Duration
+	MetricsRollingPercentileWindowBuckets  int
 }
 
 // CommandConfig is used to tune circuit settings at runtime
@@ -35,11 +83,56 @@ type CommandConfig struct {
 	RequestVolumeThreshold int `json:"request_volume_threshold"`
 	SleepWindow            int `json:"sleep_window"`
 	ErrorPercentThreshold  int `json:"error_percent_threshold"`
+	// RequestsPerSecond limits how often the command may start. Zero disables rate limiting.
+	RequestsPerSecond int `json:"requests_per_second"`
+	// Burst is how many executions may start at once when the rate limit is idle. Defaults to RequestsPerSecond.
+	Burst int `json:"burst"`
+	// MaxConcurrentRequestsPerKey limits concurrency for each key given by WithBulkheadKey. Zero disables the limit.
+	MaxConcurrentRequestsPerKey int `json:"max_concurrent_requests_per_key"`
+	// PriorityReservePercent is the share of tickets held back from each criticality below
+	// CriticalityCritical: default executions may use all but this share, and sheddable
+	// executions all but twice this share. Zero admits every criticality equally.
+	PriorityReservePercent int `json:"priority_reserve_percent"`
+	// DeadlineAdmissionPercentile rejects executions whose context deadline leaves less time than
+	// this percentile of recent run durations. Zero disables the check.
+	DeadlineAdmissionPercentile int `json:"deadline_admission_percentile"`
+	// ExecutionIsolationStrategy is either IsolationThread or IsolationSemaphore. Defaults to DefaultIsolationStrategy.
+	ExecutionIsolationStrategy string `json:"execution_isolation_strategy"`
+	// StreamFirstItemTimeout is how long, in milliseconds, ObserveC waits for the first value. Zero disables it.
+	StreamFirstItemTimeout int `json:"stream_first_item_timeout"`
+	// StreamItemTimeout is how long, in milliseconds, ObserveC waits for each value after the first. Zero disables it.
+	StreamItemTimeout int `json:"stream_item_timeout"`
+	// ShadowMode only records the executions the circuit would have short-circuited, rejected or
+	// timed out, and runs them to completion anyway. Use it to try out new settings safely.
+	ShadowMode bool `json:"shadow_mode"`
+	// SlowStartWindow is how long, in milliseconds, admitted traffic takes to ramp back up to
+	// 100% after the circuit closes. Zero lets all traffic through as soon as it closes.
+	SlowStartWindow int `json:"slow_start_window"`
+	// SlowStartRamp is either SlowStartLinear or SlowStartExponential. Defaults to SlowStartLinear.
+	SlowStartRamp string `json:"slow_start_ramp"`
+	// MetricsBufferSize is how many executions may wait to be added to the circuit's metrics.
+	// Defaults to DefaultMetricsBufferSize. It only applies to circuits created afterwards.
+	MetricsBufferSize int `json:"metrics_buffer_size"`
+	// MetricsDropPolicy is MetricsBlock, MetricsDrop or MetricsAggregate. Defaults to DefaultMetricsDropPolicy.
+	// It only applies to circuits created afterwards.
+	MetricsDropPolicy string `json:"metrics_drop_policy"`
+	// MetricsRollingStatisticalWindow is how long, in milliseconds, the counts which decide the circuit's
+	// health are kept. Defaults to DefaultMetricsRollingStatisticalWindow.
+	MetricsRollingStatisticalWindow int `json:"metrics_rolling_statistical_window"`
+	// MetricsRollingStatisticalWindowBuckets is how many buckets the statistical window is split into. Counts
+	// leave the window a bucket at a time. Defaults to DefaultMetricsRollingStatisticalWindowBuckets.
+	MetricsRollingStatisticalWindowBuckets int `json:"metrics_rolling_statistical_window_buckets"`
+	// MetricsRollingPercentileWindow is how long, in milliseconds, durations are kept to compute percentiles.
+	// Defaults to DefaultMetricsRollingPercentileWindow.
+	MetricsRollingPercentileWindow int `json:"metrics_rolling_percentile_window"`
+	// MetricsRollingPercentileWindowBuckets is how many buckets the percentile window is split into.
+	// Defaults to DefaultMetricsRollingPercentileWindowBuckets.
+	MetricsRollingPercentileWindowBuckets int `json:"metrics_rolling_percentile_window_buckets"`
 }
 
 var circuitSettings map[string]*Settings
 var settingsMutex *sync.RWMutex
-var log logger
+var log Logger
 
 func init() {
 	circuitSettings = make(map[string]*Settings)
@@ -84,12 +177,75 @@ func ConfigureCommand(name string, config CommandConfig) {
 		errorPercent = config.ErrorPercentThreshold
 	}
 
+	burst := config.RequestsPerSecond
+	if config.Burst != 0 {
+		burst = config.Burst
+	}
+
+	isolation := DefaultIsolationStrategy
+	if config.ExecutionIsolationStrategy != "" {
+		isolation = config.ExecutionIsolationStrategy
+	}
+
+	ramp := SlowStartLinear
+	if config.SlowStartRamp != "" {
+		ramp = config.SlowStartRamp
+	}
+
+	bufferSize := DefaultMetricsBufferSize
+	if config.MetricsBufferSize != 0 {
+		bufferSize = config.MetricsBufferSize
+	}
+
+	dropPolicy := DefaultMetricsDropPolicy
+	if config.MetricsDropPolicy != "" {
+		dropPolicy = config.MetricsDropPolicy
+	}
+
+	statsWindow := DefaultMetricsRollingStatisticalWindow
+	if config.MetricsRollingStatisticalWindow != 0 {
+		statsWindow = config.MetricsRollingStatisticalWindow
+	}
+
+	statsBuckets := DefaultMetricsRollingStatisticalWindowBuckets
+	if config.MetricsRollingStatisticalWindowBuckets != 0 {
+		statsBuckets = config.MetricsRollingStatisticalWindowBuckets
+	}
+
+	percentileWindow := DefaultMetricsRollingPercentileWindow
+	if config.MetricsRollingPercentileWindow != 0 {
+		percentileWindow = config.MetricsRollingPercentileWindow
+	}
+
+	percentileBuckets := DefaultMetricsRollingPercentileWindowBuckets
+	if config.MetricsRollingPercentileWindowBuckets != 0 {
+		percentileBuckets = config.MetricsRollingPercentileWindowBuckets
+	}
+
 	circuitSettings[name] = &Settings{
-		Timeout:                time.Duration(timeout) * time.Millisecond,
-		MaxConcurrentRequests:  max,
-		RequestVolumeThreshold: uint64(volume),
-		SleepWindow:            time.Duration(sleep) * time.Millisecond,
-		ErrorPercentThreshold:  errorPercent,
+		Timeout:                     time.Duration(timeout) * time.Millisecond,
+		MaxConcurrentRequests:       max,
+		RequestVolumeThreshold:      uint64(volume),
+		SleepWindow:                 time.Duration(sleep) * time.Millisecond,
+		ErrorPercentThreshold:       errorPercent,
+		RequestsPerSecond:           config.RequestsPerSecond,
+		Burst:                       burst,
+		MaxConcurrentRequestsPerKey: config.MaxConcurrentRequestsPerKey,
+		PriorityReservePercent:      config.PriorityReservePercent,
+		DeadlineAdmissionPercentile: config.DeadlineAdmissionPercentile,
+		ExecutionIsolationStrategy:  isolation,
+		StreamFirstItemTimeout:      time.Duration(config.StreamFirstItemTimeout) * time.Millisecond,
+		StreamItemTimeout:           time.Duration(config.StreamItemTimeout) * time.Millisecond,
+		ShadowMode:                  config.ShadowMode,
+		SlowStartWindow:             time.Duration(config.SlowStartWindow) * time.Millisecond,
+		SlowStartRamp:               ramp,
+		MetricsBufferSize:           bufferSize,
+		MetricsDropPolicy:           dropPolicy,
+
+		MetricsRollingStatisticalWindow:        time.Duration(statsWindow) * time.Millisecond,
+		MetricsRollingStatisticalWindowBuckets: statsBuckets,
+		MetricsRollingPercentileWindow:         time.Duration(percentileWindow) * time.Millisecond,
+		MetricsRollingPercentileWindowBuckets:  percentileBuckets,
 	}
 }
 
@@ -119,6 +275,17 @@ func GetCircuitSettings() map[string]*Settings {
 }
 
 // SetLogger configures the logger that will be used. This only applies to the hystrix package.
-func SetLogger(l logger) {
+// Loggers which only implement Printf are adapted with NewPrintfLogger.
+func SetLogger(l PrintfLogger) {
+	if structured, ok := l.(Logger); ok {
+		log = structured
+		return
+	}
+	log = NewPrintfLogger(l)
+}
+
+// SetStructuredLogger configures the logger that will be used, receiving each event
+// with its level and fields. This only applies to the hystrix package.
+func SetStructuredLogger(l Logger) {
 	log = l
 }
@@ -57,3 +57,14 @@ func TestGetCircuitSettings(t *testing.T) {
 		})
 	})
 }
+
+func TestConfigureRateLimit(t *testing.T) {
+	Convey("given a command configured for 50 requests per second", t, func() {
+		ConfigureCommand("", CommandConfig{RequestsPerSecond: 50})
+
+		Convey("the burst defaults to the rate", func() {
+			So(getSettings("").RequestsPerSecond, ShouldEqual, 50)
+			So(getSettings("").Burst, ShouldEqual, 50)
+		})
+	})
+}
@@ -0,0 +1,25 @@
+package hystrix
+
+// shadowEventPrefix marks events recording what a command in shadow mode would
+// have done, such as "shadow-timeout" for an execution which would have timed out.
+const shadowEventPrefix = "shadow-"
+
+// recordShadowEvent records that shadow mode kept err from failing the command.
+// Callers must hold the command's lock while other goroutines can reach it.
+func (c *command) recordShadowEvent(err error) {
+	event := shadowEventPrefix + eventTypeFor(err)
+	c.shadowEvents = append(c.shadowEvents, event)
+
+	log.Log(LogLevelInfo, "shadow mode let execution through",
+		LogField{"circuit", c.circuit.Name}, LogField{"event", event})
+}
+
+// has reports whether the execution includes the event.
+func (e *commandExecution) has(event string) bool {
+	for _, t := range e.Types {
+		if t == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package hystrix_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/afex/hystrix-go/hystrix/clock"
+	"github.com/afex/hystrix-go/hystrix/hystrixtest"
+	"github.com/afex/hystrix-go/hystrix/metric_collector"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type shadowLog struct {
+	level  hystrix.LogLevel
+	msg    string
+	fields []hystrix.LogField
+}
+
+// shadowLogger sends the lines shadow mode logs on logs, ignoring any others.
+type shadowLogger struct {
+	logs chan shadowLog
+}
+
+func (l shadowLogger) Log(level hystrix.LogLevel, msg string, fields ...hystrix.LogField) {
+	if msg == "shadow mode let execution through" {
+		l.logs <- shadowLog{level, msg, fields}
+	}
+}
+
+func TestShadowMode(t *testing.T) {
+	Convey("with a command in shadow mode", t, func() {
+		hystrixtest.Isolate(t)
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		logger := shadowLogger{logs: make(chan shadowLog, 10)}
+		hystrix.SetStructuredLogger(logger)
+		defer hystrix.SetStructuredLogger(hystrix.DefaultLogger)
+		hystrix.ConfigureCommand("shadow", hystrix.CommandConfig{Timeout: 10, MaxConcurrentRequests: 1, ShadowMode: true})
+		recorder := hystrixtest.RecordMetrics(t, "shadow")
+
+		Convey("an open circuit still runs the command", func() {
+			hystrixtest.SetState(t, "shadow", hystrix.CircuitOpen)
+
+			var ran int32
+			err := hystrix.DoC(context.Background(), "shadow", func(ctx context.Context) error {
+				atomic.StoreInt32(&ran, 1)
+				return nil
+			}, nil)
+			So(err, ShouldBeNil)
+			So(atomic.LoadInt32(&ran), ShouldEqual, 1)
+
+			Convey("recording the short circuit without closing the circuit", func() {
+				recorder.Wait(t, 1)
+				recorder.AssertCounts(t, metricCollector.MetricResult{
+					Attempts:            1,
+					Successes:           1,
+					ShadowShortCircuits: 1,
+				})
+				cb, _, _ := hystrix.GetCircuit("shadow")
+				So(cb.IsOpen(), ShouldBeTrue)
+
+				So(<-logger.logs, ShouldResemble, shadowLog{hystrix.LogLevelInfo, "shadow mode let execution through",
+					[]hystrix.LogField{{"circuit", "shadow"}, {"event", "shadow-short-circuit"}}})
+			})
+		})
+
+		// runFor runs a command which finishes once the clock has moved on by d.
+		runFor := func(d time.Duration) error {
+			started := make(chan struct{})
+			release := make(chan struct{})
+			errChan := make(chan error)
+			go func() {
+				errChan <- hystrix.DoC(context.Background(), "shadow", func(ctx context.Context) error {
+					close(started)
+					<-release
+					return nil
+				}, func(ctx context.Context, err error) error {
+					return err
+				})
+			}()
+
+			<-started
+			fake.WaitForTimers(1)
+			fake.Advance(d)
+			close(release)
+			return <-errChan
+		}
+
+		Convey("a command finishing right at its timeout is not timed out", func() {
+			So(runFor(10*time.Millisecond), ShouldBeNil)
+
+			Convey("but the timeout is recorded once", func() {
+				recorder.Wait(t, 1)
+				recorder.AssertCounts(t, metricCollector.MetricResult{
+					Attempts:       1,
+					Successes:      1,
+					ShadowTimeouts: 1,
+				})
+				So(<-logger.logs, ShouldResemble, shadowLog{hystrix.LogLevelInfo, "shadow mode let execution through",
+					[]hystrix.LogField{{"circuit", "shadow"}, {"event", "shadow-timeout"}}})
+			})
+		})
+
+		Convey("a command finishing just before its timeout records no timeout", func() {
+			So(runFor(9*time.Millisecond), ShouldBeNil)
+
+			recorder.Wait(t, 1)
+			recorder.AssertCounts(t, metricCollector.MetricResult{
+				Attempts:  1,
+				Successes: 1,
+			})
+		})
+
+		Convey("a command beyond the concurrency limit is not rejected", func() {
+			unblock := blockOne(context.Background(), "shadow")
+
+			So(hystrix.DoC(context.Background(), "shadow", succeed, nil), ShouldBeNil)
+			unblock()
+
+			Convey("but the rejection is recorded, without holding on to a ticket", func() {
+				recorder.Wait(t, 2)
+				So(hystrix.DoC(context.Background(), "shadow", succeed, nil), ShouldBeNil)
+
+				recorder.Wait(t, 3)
+				recorder.AssertCounts(t, metricCollector.MetricResult{
+					Attempts:      3,
+					Successes:     3,
+					ShadowRejects: 1,
+				})
+			})
+		})
+	})
+
+	Convey("with a command isolated by semaphore in shadow mode", t, func() {
+		hystrixtest.Isolate(t)
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		hystrix.ConfigureCommand("shadow", hystrix.CommandConfig{Timeout: 10, ExecutionIsolationStrategy: hystrix.IsolationSemaphore, ShadowMode: true})
+		recorder := hystrixtest.RecordMetrics(t, "shadow")
+
+		Convey("the context passed to run is not cancelled at the timeout", func() {
+			err := hystrix.DoC(context.Background(), "shadow", func(ctx context.Context) error {
+				fake.Advance(30 * time.Millisecond)
+				return ctx.Err()
+			}, nil)
+			So(err, ShouldBeNil)
+
+			Convey("but the timeout is recorded", func() {
+				recorder.Wait(t, 1)
+				So(recorder.Total().ShadowTimeouts, ShouldEqual, 1)
+			})
+		})
+	})
+}
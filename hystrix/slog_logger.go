@@ -0,0 +1,40 @@
+//go:build go1.21
+// +build go1.21
+
+package hystrix
+
+import (
+	"context"
+	"log/slog"
+)
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger adapts a *slog.Logger into a Logger, mapping each LogLevel to
+// the slog level of the same name and each LogField to an attribute.
+func NewSlogLogger(l *slog.Logger) Logger {
+	return slogLogger{logger: l}
+}
+
+func (l slogLogger) Log(level LogLevel, msg string, fields ...LogField) {
+	attrs := make([]slog.Attr, len(fields))
+	for i, field := range fields {
+		attrs[i] = slog.Any(field.Key, field.Value)
+	}
+
+	l.logger.LogAttrs(context.Background(), slogLevel(level), msg, attrs...)
+}
+
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LogLevelDebug:
+		return slog.LevelDebug
+	case LogLevelWarn:
+		return slog.LevelWarn
+	case LogLevelError:
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
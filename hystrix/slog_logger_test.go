@@ -0,0 +1,34 @@
+//go:build go1.21
+// +build go1.21
+
+package hystrix
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSlogLogger(t *testing.T) {
+	Convey("with a slog logger which only handles warnings", t, func() {
+		buf := &bytes.Buffer{}
+		handler := slog.NewTextHandler(buf, &slog.HandlerOptions{
+			Level: slog.LevelWarn,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		})
+		logger := NewSlogLogger(slog.New(handler))
+
+		Convey("events are logged at their level with their fields as attributes", func() {
+			logger.Log(LogLevelInfo, "closing circuit", LogField{"circuit", "foo"})
+			logger.Log(LogLevelWarn, "opening circuit", LogField{"circuit", "foo"}, LogField{"state", CircuitOpen})
+			So(buf.String(), ShouldEqual, "level=WARN msg=\"opening circuit\" circuit=foo state=OPEN\n")
+		})
+	})
+}
@@ -0,0 +1,62 @@
+package hystrix
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	// SlowStartLinear ramps admitted traffic up at a constant rate.
+	SlowStartLinear = "LINEAR"
+	// SlowStartExponential ramps admitted traffic up slowly at first, doubling
+	// at a constant rate, so that most of the increase comes late in the window.
+	SlowStartExponential = "EXPONENTIAL"
+)
+
+// slowStartInitialPercent is the share of traffic admitted as soon as a circuit closes.
+const slowStartInitialPercent = 5.0
+
+// SlowStartPercent returns the percentage of traffic the circuit currently
+// admits while ramping back up after closing. It is 100 once the ramp is over.
+func (circuit *CircuitBreaker) SlowStartPercent() float64 {
+	return circuit.slowStartPercent(circuit.clock.Now())
+}
+
+func (circuit *CircuitBreaker) slowStartPercent(now time.Time) float64 {
+	settings := getSettings(circuit.Name)
+	if settings.SlowStartWindow <= 0 {
+		return 100
+	}
+
+	circuit.mutex.RLock()
+	closedTime := circuit.closedTime
+	circuit.mutex.RUnlock()
+	if closedTime == 0 {
+		return 100
+	}
+
+	elapsed := now.Sub(time.Unix(0, closedTime))
+	if elapsed >= settings.SlowStartWindow {
+		return 100
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	progress := float64(elapsed) / float64(settings.SlowStartWindow)
+	if settings.SlowStartRamp == SlowStartExponential {
+		return slowStartInitialPercent * math.Pow(100/slowStartInitialPercent, progress)
+	}
+	return slowStartInitialPercent + (100-slowStartInitialPercent)*progress
+}
+
+// allowDuringSlowStart decides at random whether to admit an execution, in
+// proportion to the share of traffic the ramp currently allows.
+func (circuit *CircuitBreaker) allowDuringSlowStart() bool {
+	percent := circuit.slowStartPercent(circuit.clock.Now())
+	if percent >= 100 {
+		return true
+	}
+	return rand.Float64()*100 < percent
+}
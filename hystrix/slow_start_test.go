@@ -0,0 +1,59 @@
+package hystrix
+
+import (
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSlowStart(t *testing.T) {
+	Convey("with a circuit which has just closed", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+		defer Flush()
+
+		Convey("and ramps up linearly", func() {
+			ConfigureCommand("", CommandConfig{SlowStartWindow: 1000})
+			cb, _, _ := GetCircuit("")
+			cb.setOpen()
+			cb.setClose()
+
+			Convey("traffic ramps from a small share to all of it over the window", func() {
+				So(cb.SlowStartPercent(), ShouldEqual, slowStartInitialPercent)
+				fake.Advance(500 * time.Millisecond)
+				So(cb.SlowStartPercent(), ShouldAlmostEqual, 52.5)
+				fake.Advance(500 * time.Millisecond)
+				So(cb.SlowStartPercent(), ShouldEqual, 100)
+			})
+		})
+
+		Convey("and ramps up exponentially", func() {
+			ConfigureCommand("", CommandConfig{SlowStartWindow: 1000, SlowStartRamp: SlowStartExponential})
+			cb, _, _ := GetCircuit("")
+			cb.setOpen()
+			cb.setClose()
+
+			Convey("most of the increase comes late in the window", func() {
+				So(cb.SlowStartPercent(), ShouldEqual, slowStartInitialPercent)
+				fake.Advance(500 * time.Millisecond)
+				So(cb.SlowStartPercent(), ShouldAlmostEqual, 22.36, 0.01)
+				fake.Advance(500 * time.Millisecond)
+				So(cb.SlowStartPercent(), ShouldEqual, 100)
+			})
+		})
+
+		Convey("without a slow start window", func() {
+			ConfigureCommand("", CommandConfig{})
+			cb, _, _ := GetCircuit("")
+			cb.setOpen()
+			cb.setClose()
+
+			Convey("all traffic is admitted at once", func() {
+				So(cb.SlowStartPercent(), ShouldEqual, 100)
+			})
+		})
+	})
+}
@@ -0,0 +1,151 @@
+package hystrix
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+)
+
+// ErrNoStaleValue occurs when a stale fallback has no recent enough value to serve.
+var ErrNoStaleValue = CircuitError{Message: "no stale value"}
+
+// A StaleCache holds the last successful result of a command for each key,
+// so that DoStaleC can serve it when the command fails.
+type StaleCache struct {
+	// MaxAge is how long a value may be served after it was stored. Zero means values never expire.
+	MaxAge time.Duration
+	// MaxEntries caps the number of keys held, dropping the least recently stored first. Zero means no cap.
+	MaxEntries int
+
+	mutex   *sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	clock   clock.Clock
+}
+
+type staleEntry struct {
+	key    string
+	value  interface{}
+	stored time.Time
+}
+
+// NewStaleCache returns an empty cache with the given limits, which ages its
+// values by the default clock.
+func NewStaleCache(maxAge time.Duration, maxEntries int) *StaleCache {
+	c := &StaleCache{}
+	c.MaxAge = maxAge
+	c.MaxEntries = maxEntries
+	c.mutex = &sync.Mutex{}
+	c.entries = make(map[string]*list.Element)
+	c.order = list.New()
+	c.clock = clock.Default()
+
+	return c
+}
+
+// Set stores value as the last known good value for key.
+func (c *StaleCache) Set(key string, value interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+	}
+	c.entries[key] = c.order.PushFront(&staleEntry{key: key, value: value, stored: c.clock.Now()})
+
+	for c.MaxEntries > 0 && c.order.Len() > c.MaxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*staleEntry).key)
+	}
+}
+
+// Get returns the value stored for key, if it is no older than MaxAge.
+func (c *StaleCache) Get(key string) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*staleEntry)
+	if c.MaxAge > 0 && c.clock.Now().Sub(entry.stored) > c.MaxAge {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+// Len returns the number of keys held, including any which have expired but not yet been read.
+func (c *StaleCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.order.Len()
+}
+
+// DoStaleC runs your function in a synchronous manner like DoC, storing each successful
+// result in cache under key. When the function fails, times out or is rejected, the last
+// result stored for key is returned instead and a "fallback-stale" event is reported.
+// If there is no such result the fallback fails with ErrNoStaleValue.
+func DoStaleC(ctx context.Context, name string, cache *StaleCache, key string, run func(context.Context) (interface{}, error)) (interface{}, error) {
+	// buffered so that a run which finishes too late doesn't block once we have returned
+	fresh := make(chan interface{}, 1)
+	stale := make(chan interface{}, 1)
+
+	cmd := newCommand(ctx, func(ctx context.Context) error {
+		value, err := run(ctx)
+		if err != nil {
+			return err
+		}
+
+		cache.Set(key, value)
+		fresh <- value
+		return nil
+	}, func(ctx context.Context, err error) error {
+		value, ok := cache.Get(key)
+		if !ok {
+			return ErrNoStaleValue
+		}
+
+		stale <- value
+		return nil
+	})
+	cmd.staleFallback = true
+
+	var err error
+	if getSettings(name).ExecutionIsolationStrategy == IsolationSemaphore {
+		err = doSemaphoreC(ctx, name, cmd)
+	} else {
+		errChan := goC(ctx, name, cmd)
+		select {
+		case err = <-errChan:
+		case <-cmd.reported:
+			// a failed fallback sends its error before the events are reported.
+			select {
+			case err = <-errChan:
+			default:
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Both values may be ready when a run finishes just as it times out, so
+	// return the one the reported events describe.
+	cmd.Lock()
+	succeeded := cmd.events[0] == "success"
+	cmd.Unlock()
+	if succeeded {
+		return <-fresh, nil
+	}
+	return <-stale, nil
+}
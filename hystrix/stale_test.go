@@ -0,0 +1,141 @@
+package hystrix_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/afex/hystrix-go/hystrix/clock"
+	"github.com/afex/hystrix-go/hystrix/hystrixtest"
+	"github.com/afex/hystrix-go/hystrix/metric_collector"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStaleCache(t *testing.T) {
+	Convey("with a cache holding at most 2 entries", t, func() {
+		cache := hystrix.NewStaleCache(time.Hour, 2)
+
+		Convey("the least recently stored entry is dropped first", func() {
+			cache.Set("a", 1)
+			cache.Set("b", 2)
+			cache.Set("a", 3)
+			cache.Set("c", 4)
+
+			So(cache.Len(), ShouldEqual, 2)
+			_, ok := cache.Get("b")
+			So(ok, ShouldBeFalse)
+			value, ok := cache.Get("a")
+			So(ok, ShouldBeTrue)
+			So(value, ShouldEqual, 3)
+		})
+	})
+
+	Convey("with a cache whose values expire after a second", t, func() {
+		fake := clock.NewFake(time.Now())
+		clock.SetDefault(fake)
+		defer clock.SetDefault(clock.Real())
+
+		cache := hystrix.NewStaleCache(time.Second, 0)
+		cache.Set("a", 1)
+
+		Convey("values are not served once they are too old", func() {
+			fake.Advance(time.Second + time.Millisecond)
+
+			_, ok := cache.Get("a")
+			So(ok, ShouldBeFalse)
+			So(cache.Len(), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestDoStaleC(t *testing.T) {
+	Convey("with a command which has succeeded for a key", t, func() {
+		hystrixtest.Isolate(t)
+		hystrix.ConfigureCommand("stale", hystrix.CommandConfig{Timeout: 10})
+		recorder := hystrixtest.RecordMetrics(t, "stale")
+
+		cache := hystrix.NewStaleCache(time.Hour, 10)
+		value, err := hystrix.DoStaleC(context.Background(), "stale", cache, "key", func(ctx context.Context) (interface{}, error) {
+			return "fresh", nil
+		})
+		So(err, ShouldBeNil)
+		So(value, ShouldEqual, "fresh")
+		recorder.Wait(t, 1)
+		recorder.Reset()
+
+		Convey("a failure serves the last known good value", func() {
+			value, err := hystrix.DoStaleC(context.Background(), "stale", cache, "key", func(ctx context.Context) (interface{}, error) {
+				return nil, fmt.Errorf("unavailable")
+			})
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "fresh")
+
+			Convey("and is recorded as a stale fallback", func() {
+				recorder.Wait(t, 1)
+				recorder.AssertCounts(t, metricCollector.MetricResult{
+					Attempts:          1,
+					Errors:            1,
+					Failures:          1,
+					FallbackSuccesses: 1,
+					FallbackStale:     1,
+				})
+			})
+		})
+
+		Convey("a timeout serves the last known good value rather than the late one", func() {
+			release := make(chan struct{})
+			defer close(release)
+
+			value, err := hystrix.DoStaleC(context.Background(), "stale", cache, "key", func(ctx context.Context) (interface{}, error) {
+				<-release
+				return "late", nil
+			})
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "fresh")
+
+			recorder.Wait(t, 1)
+			recorder.AssertCounts(t, metricCollector.MetricResult{
+				Attempts:          1,
+				Errors:            1,
+				Timeouts:          1,
+				FallbackSuccesses: 1,
+				FallbackStale:     1,
+			})
+		})
+
+		Convey("a short circuit serves the last known good value", func() {
+			hystrixtest.SetState(t, "stale", hystrix.CircuitOpen)
+
+			value, err := hystrix.DoStaleC(context.Background(), "stale", cache, "key", func(ctx context.Context) (interface{}, error) {
+				return "unreachable", nil
+			})
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "fresh")
+		})
+
+		Convey("a failure for another key has nothing to serve", func() {
+			_, err := hystrix.DoStaleC(context.Background(), "stale", cache, "other", func(ctx context.Context) (interface{}, error) {
+				return nil, fmt.Errorf("unavailable")
+			})
+			So(err.Error(), ShouldEqual, "fallback failed with 'hystrix: no stale value'. run error was 'unavailable'")
+		})
+	})
+
+	Convey("with a command isolated by semaphore", t, func() {
+		hystrixtest.Isolate(t)
+		hystrix.ConfigureCommand("stale", hystrix.CommandConfig{ExecutionIsolationStrategy: hystrix.IsolationSemaphore})
+
+		cache := hystrix.NewStaleCache(time.Hour, 10)
+		cache.Set("key", "stale")
+
+		Convey("a failure serves the last known good value", func() {
+			value, err := hystrix.DoStaleC(context.Background(), "stale", cache, "key", func(ctx context.Context) (interface{}, error) {
+				return nil, fmt.Errorf("unavailable")
+			})
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "stale")
+		})
+	})
+}
@@ -0,0 +1,26 @@
+package hystrix
+
+import "context"
+
+type tagsContextKey struct{}
+
+// WithTags returns a context whose command executions carry the given tags to
+// metric collectors, so that metrics can be broken down by caller, tenant or
+// endpoint. Tags already on ctx are kept unless overridden by tags.
+func WithTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := make(map[string]string, len(tags))
+	for k, v := range tagsFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+
+	return context.WithValue(ctx, tagsContextKey{}, merged)
+}
+
+// tagsFromContext returns the tags on ctx. The map is shared, so it must not be modified.
+func tagsFromContext(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(tagsContextKey{}).(map[string]string)
+	return tags
+}
@@ -0,0 +1,27 @@
+package hystrix_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/afex/hystrix-go/hystrix/hystrixtest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWithTags(t *testing.T) {
+	Convey("with a context tagged twice", t, func() {
+		hystrixtest.Isolate(t)
+		recorder := hystrixtest.RecordMetrics(t, "tagged")
+
+		ctx := hystrix.WithTags(context.Background(), map[string]string{"tenant": "a", "endpoint": "/users"})
+		ctx = hystrix.WithTags(ctx, map[string]string{"tenant": "b"})
+
+		Convey("commands executed under it carry the merged tags to collectors, later tags taking precedence", func() {
+			So(hystrix.DoC(ctx, "tagged", succeed, nil), ShouldBeNil)
+
+			results := recorder.Wait(t, 1)
+			So(results[0].Tags, ShouldResemble, map[string]string{"tenant": "b", "endpoint": "/users"})
+		})
+	})
+}
@@ -0,0 +1,172 @@
+package hystrix
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/afex/hystrix-go/hystrix/clock"
+	"github.com/afex/hystrix-go/hystrix/internal/testhooks"
+	"github.com/afex/hystrix-go/hystrix/metric_collector"
+)
+
+func init() {
+	testhooks.SetCircuitState = func(name string, state string) error {
+		circuit, _, err := GetCircuit(name)
+		if err != nil {
+			return err
+		}
+		return circuit.forceState(CircuitState(state))
+	}
+
+	testhooks.StartSlowStart = func(name string) error {
+		circuit, _, err := GetCircuit(name)
+		if err != nil {
+			return err
+		}
+		circuit.startSlowStart()
+		return nil
+	}
+
+	testhooks.InjectErrors = func(name string, n int, err error) error {
+		circuit, _, getErr := GetCircuit(name)
+		if getErr != nil {
+			return getErr
+		}
+		circuit.injectErrors(n, err)
+		return nil
+	}
+
+	testhooks.AddCollector = func(name string, collector metricCollector.MetricCollector) error {
+		circuit, _, err := GetCircuit(name)
+		if err != nil {
+			return err
+		}
+
+		circuit.metrics.addCollector(collector)
+		return nil
+	}
+
+	testhooks.Isolate = isolate
+}
+
+// forceState puts the circuit into state regardless of its metrics. A half open
+// circuit is open with its sleep window elapsed, so that it allows a single test.
+// A closed circuit admits all traffic straight away, without a slow start.
+func (circuit *CircuitBreaker) forceState(state CircuitState) error {
+	switch state {
+	case CircuitOpen:
+		circuit.setOpen()
+		atomic.StoreInt64(&circuit.openedOrLastTestedTime, circuit.clock.Now().UnixNano())
+	case CircuitHalfOpen:
+		circuit.setOpen()
+		atomic.StoreInt64(&circuit.openedOrLastTestedTime, 0)
+	case CircuitClosed:
+		circuit.toggleForceOpen(false)
+		circuit.setClose()
+		circuit.mutex.Lock()
+		circuit.closedTime = 0
+		circuit.mutex.Unlock()
+		circuit.metrics.Reset()
+	default:
+		return fmt.Errorf("unknown circuit state %v", state)
+	}
+	return nil
+}
+
+// startSlowStart closes the circuit as if it had just recovered, so that it
+// ramps traffic back up over its SlowStartWindow.
+func (circuit *CircuitBreaker) startSlowStart() {
+	circuit.toggleForceOpen(false)
+	circuit.setOpen()
+	circuit.setClose()
+}
+
+// injectErrors makes the next n executions fail with err instead of running.
+func (circuit *CircuitBreaker) injectErrors(n int, err error) {
+	circuit.mutex.Lock()
+	defer circuit.mutex.Unlock()
+
+	for i := 0; i < n; i++ {
+		circuit.injectedErrors = append(circuit.injectedErrors, err)
+	}
+	atomic.AddInt64(&circuit.pendingInjections, int64(n))
+}
+
+// takeInjectedError returns the next injected error, if there is one. Outside
+// of tests there never is, so it only checks the counter.
+func (circuit *CircuitBreaker) takeInjectedError() error {
+	if atomic.LoadInt64(&circuit.pendingInjections) == 0 {
+		return nil
+	}
+
+	circuit.mutex.Lock()
+	defer circuit.mutex.Unlock()
+
+	if len(circuit.injectedErrors) == 0 {
+		return nil
+	}
+	err := circuit.injectedErrors[0]
+	circuit.injectedErrors = circuit.injectedErrors[1:]
+	atomic.AddInt64(&circuit.pendingInjections, -1)
+	return err
+}
+
+// isolate swaps the global settings, circuits, faults, tracer, logger and
+// eviction config for empty ones, returning a function which closes any
+// circuits created since and restores the originals.
+func isolate() func() {
+	savedLogger := log
+	log = DefaultLogger
+
+	tracerMutex.Lock()
+	savedTracer := tracer
+	tracer = nil
+	tracerMutex.Unlock()
+
+	faultsMutex.Lock()
+	savedFaults := faults
+	faults = make(map[string]FaultConfig)
+	faultsMutex.Unlock()
+
+	// stop evicting the saved circuits before swapping them out, so that the
+	// eviction loop never sees the isolated ones.
+	savedEviction, savedEvictionClock := getEviction()
+	configureEviction(EvictionConfig{}, clock.Default())
+
+	settingsMutex.Lock()
+	savedSettings := circuitSettings
+	circuitSettings = make(map[string]*Settings)
+	settingsMutex.Unlock()
+
+	circuitBreakersMutex.Lock()
+	savedCircuits := circuitBreakers
+	circuitBreakers = make(map[string]*CircuitBreaker)
+	circuitBreakersMutex.Unlock()
+
+	return func() {
+		circuitBreakersMutex.Lock()
+		created := circuitBreakers
+		circuitBreakers = savedCircuits
+		circuitBreakersMutex.Unlock()
+
+		for _, cb := range created {
+			cb.Close()
+		}
+
+		settingsMutex.Lock()
+		circuitSettings = savedSettings
+		settingsMutex.Unlock()
+
+		configureEviction(savedEviction, savedEvictionClock)
+
+		faultsMutex.Lock()
+		faults = savedFaults
+		faultsMutex.Unlock()
+
+		tracerMutex.Lock()
+		tracer = savedTracer
+		tracerMutex.Unlock()
+
+		log = savedLogger
+	}
+}
@@ -0,0 +1,83 @@
+package hystrix
+
+import (
+	"context"
+	"sync"
+)
+
+// A Tracer observes command executions, typically to record them as trace spans.
+// See plugins.NewOpenTelemetryTracer for an OpenTelemetry implementation.
+type Tracer interface {
+	// StartCommand is called as the named command begins to execute. The
+	// returned context is used for the rest of the execution, including run.
+	StartCommand(ctx context.Context, name string) (context.Context, CommandSpan)
+}
+
+// A CommandSpan traces a single command execution.
+type CommandSpan interface {
+	// StartRun is called before run, returning the context to call it with and
+	// a function which is called with its error once it returns.
+	StartRun(ctx context.Context) (context.Context, func(error))
+	// StartFallback is called before the fallback, in the same way as StartRun.
+	StartFallback(ctx context.Context) (context.Context, func(error))
+	// End is called once the execution's events have been reported, along with
+	// the share of the pool in use when they were.
+	End(events []string, concurrencyInUse float64)
+}
+
+var (
+	tracerMutex *sync.RWMutex
+	tracer      Tracer
+)
+
+func init() {
+	tracerMutex = &sync.RWMutex{}
+}
+
+// SetTracer configures the tracer used for every command execution. A nil tracer disables tracing.
+func SetTracer(t Tracer) {
+	tracerMutex.Lock()
+	defer tracerMutex.Unlock()
+
+	tracer = t
+}
+
+func getTracer() Tracer {
+	tracerMutex.RLock()
+	defer tracerMutex.RUnlock()
+
+	return tracer
+}
+
+// startTrace opens the command's span, if a tracer is configured, returning the
+// context to execute the command with.
+func (c *command) startTrace(ctx context.Context, name string) context.Context {
+	t := getTracer()
+	if t == nil {
+		return ctx
+	}
+
+	ctx, c.span = t.StartCommand(ctx, name)
+	return ctx
+}
+
+func (c *command) startRunTrace(ctx context.Context) (context.Context, func(error)) {
+	if c.span == nil {
+		return ctx, func(error) {}
+	}
+	return c.span.StartRun(ctx)
+}
+
+func (c *command) startFallbackTrace(ctx context.Context) (context.Context, func(error)) {
+	if c.span == nil {
+		return ctx, func(error) {}
+	}
+	return c.span.StartFallback(ctx)
+}
+
+func (c *command) endTrace(concurrencyInUse float64) {
+	if c.span == nil {
+		return
+	}
+	c.span.End(append([]string(nil), c.events...), concurrencyInUse)
+}
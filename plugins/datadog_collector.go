@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"sort"
 
 	// Developed on https://github.com/DataDog/datadog-go/tree/a27810dd518c69be741a7fd5d0e39f674f615be8
 	"github.com/DataDog/datadog-go/statsd"
@@ -26,6 +27,18 @@ const (
 	DM_FallbackFailures  = "hystrix.fallbackFailures"
 	DM_TotalDuration     = "hystrix.totalDuration"
 	DM_RunDuration       = "hystrix.runDuration"
+
+	DM_RateLimited          = "hystrix.rateLimited"
+	DM_InsufficientDeadline = "hystrix.insufficientDeadline"
+	DM_SlowStartRejects     = "hystrix.slowStartRejects"
+	DM_Shed                 = "hystrix.shed"
+	DM_FallbackStale        = "hystrix.fallbackStale"
+	DM_FaultInjected        = "hystrix.faultInjected"
+	DM_ShadowShortCircuits  = "hystrix.shadowShortCircuits"
+	DM_ShadowRejects        = "hystrix.shadowRejects"
+	DM_ShadowTimeouts       = "hystrix.shadowTimeouts"
+	DM_DroppedUpdates       = "hystrix.droppedUpdates"
+	DM_MonitorLag           = "hystrix.monitorLag"
 )
 
 type (
@@ -115,41 +128,114 @@ func NewDatadogCollectorWithClient(client DatadogClient) func(string) metricColl
 }
 
 func (dc *DatadogCollector) Update(r metricCollector.MetricResult) {
+	tags := dc.resultTags(r)
+
 	if r.Attempts > 0 {
-		dc.client.Count(DM_Attempts, int64(r.Attempts), dc.tags, 1.0)
+		dc.client.Count(DM_Attempts, int64(r.Attempts), tags, 1.0)
 	}
 	if r.Errors > 0 {
-		dc.client.Count(DM_Errors, int64(r.Errors), dc.tags, 1.0)
+		dc.client.Count(DM_Errors, int64(r.Errors), tags, 1.0)
 	}
 	if r.Successes > 0 {
-		dc.client.Gauge(DM_CircuitOpen, 0, dc.tags, 1.0)
-		dc.client.Count(DM_Successes, int64(r.Successes), dc.tags, 1.0)
+		dc.client.Gauge(DM_CircuitOpen, 0, tags, 1.0)
+		dc.client.Count(DM_Successes, int64(r.Successes), tags, 1.0)
 	}
 	if r.Failures > 0 {
-		dc.client.Count(DM_Failures, int64(r.Failures), dc.tags, 1.0)
+		dc.client.Count(DM_Failures, int64(r.Failures), tags, 1.0)
 	}
 	if r.Rejects > 0 {
-		dc.client.Count(DM_Rejects, int64(r.Rejects), dc.tags, 1.0)
+		dc.client.Count(DM_Rejects, int64(r.Rejects), dc.rejectTags(r), 1.0)
 	}
 	if r.ShortCircuits > 0 {
-		dc.client.Gauge(DM_CircuitOpen, 1, dc.tags, 1.0)
-		dc.client.Count(DM_ShortCircuits, int64(r.ShortCircuits), dc.tags, 1.0)
+		dc.client.Gauge(DM_CircuitOpen, 1, tags, 1.0)
+		dc.client.Count(DM_ShortCircuits, int64(r.ShortCircuits), tags, 1.0)
 	}
 	if r.Timeouts > 0 {
-		dc.client.Count(DM_Timeouts, int64(r.Timeouts), dc.tags, 1.0)
+		dc.client.Count(DM_Timeouts, int64(r.Timeouts), tags, 1.0)
+	}
+	if r.RateLimited > 0 {
+		dc.client.Count(DM_RateLimited, int64(r.RateLimited), tags, 1.0)
+	}
+	if r.InsufficientDeadline > 0 {
+		dc.client.Count(DM_InsufficientDeadline, int64(r.InsufficientDeadline), tags, 1.0)
+	}
+	if r.SlowStartRejects > 0 {
+		dc.client.Count(DM_SlowStartRejects, int64(r.SlowStartRejects), tags, 1.0)
+	}
+	if r.Shed > 0 {
+		dc.client.Count(DM_Shed, int64(r.Shed), tags, 1.0)
 	}
 	if r.FallbackSuccesses > 0 {
-		dc.client.Count(DM_FallbackSuccesses, int64(r.FallbackSuccesses), dc.tags, 1.0)
+		dc.client.Count(DM_FallbackSuccesses, int64(r.FallbackSuccesses), tags, 1.0)
 	}
 	if r.FallbackFailures > 0 {
-		dc.client.Count(DM_FallbackFailures, int64(r.FallbackFailures), dc.tags, 1.0)
+		dc.client.Count(DM_FallbackFailures, int64(r.FallbackFailures), tags, 1.0)
+	}
+	if r.FallbackStale > 0 {
+		dc.client.Count(DM_FallbackStale, int64(r.FallbackStale), tags, 1.0)
+	}
+	if r.FaultInjected > 0 {
+		dc.client.Count(DM_FaultInjected, int64(r.FaultInjected), tags, 1.0)
+	}
+	if r.ShadowShortCircuits > 0 {
+		dc.client.Count(DM_ShadowShortCircuits, int64(r.ShadowShortCircuits), tags, 1.0)
+	}
+	if r.ShadowRejects > 0 {
+		dc.client.Count(DM_ShadowRejects, int64(r.ShadowRejects), tags, 1.0)
+	}
+	if r.ShadowTimeouts > 0 {
+		dc.client.Count(DM_ShadowTimeouts, int64(r.ShadowTimeouts), tags, 1.0)
+	}
+
+	if r.DroppedUpdates > 0 {
+		dc.client.Count(DM_DroppedUpdates, int64(r.DroppedUpdates), tags, 1.0)
+	}
+	if r.Aggregated {
+		return
 	}
 
 	ms := float64(r.TotalDuration.Nanoseconds() / 1000000)
-	dc.client.TimeInMilliseconds(DM_TotalDuration, ms, dc.tags, 1.0)
+	dc.client.TimeInMilliseconds(DM_TotalDuration, ms, tags, 1.0)
 
-	ms = float64(r.RunDuration.Nanoseconds() / 1000000)
-	dc.client.TimeInMilliseconds(DM_RunDuration, ms, dc.tags, 1.0)
+	if r.Ran() {
+		ms = float64(r.RunDuration.Nanoseconds() / 1000000)
+		dc.client.TimeInMilliseconds(DM_RunDuration, ms, tags, 1.0)
+	}
+
+	ms = float64(r.MonitorLag.Nanoseconds() / 1000000)
+	dc.client.TimeInMilliseconds(DM_MonitorLag, ms, tags, 1.0)
+}
+
+// resultTags adds the execution's context tags to the circuit tags, in key order,
+// and marks executions which had faults injected.
+func (dc *DatadogCollector) resultTags(r metricCollector.MetricResult) []string {
+	tags := dc.tags[:len(dc.tags):len(dc.tags)]
+	if r.FaultInjected > 0 {
+		tags = append(tags, "faultinjected:true")
+	}
+
+	keys := make([]string, 0, len(r.Tags))
+	for k := range r.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		tags = append(tags, k+":"+r.Tags[k])
+	}
+
+	return tags
+}
+
+// rejectTags attributes a rejection to the bulkhead key and criticality it was made for.
+func (dc *DatadogCollector) rejectTags(r metricCollector.MetricResult) []string {
+	tags := dc.resultTags(r)
+	if r.BulkheadKey != "" {
+		tags = append(tags, "bulkheadkey:"+r.BulkheadKey)
+	}
+	if r.Criticality != "" {
+		tags = append(tags, "criticality:"+r.Criticality)
+	}
+	return tags
 }
 
 // Reset is a noop operation in this collector.
@@ -0,0 +1,50 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/afex/hystrix-go/hystrix/metric_collector"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRejectTags(t *testing.T) {
+	Convey("given a datadog collector for a circuit", t, func() {
+		collector := NewDatadogCollectorWithClient(nil)("foo").(*DatadogCollector)
+
+		Convey("a rejection is tagged with its bulkhead key and criticality", func() {
+			tags := collector.rejectTags(metricCollector.MetricResult{BulkheadKey: "tenant", Criticality: "SHEDDABLE"})
+			So(tags, ShouldResemble, []string{"hystrixcircuit:foo", "bulkheadkey:tenant", "criticality:SHEDDABLE"})
+
+			Convey("without changing the circuit tags", func() {
+				So(collector.tags, ShouldResemble, []string{"hystrixcircuit:foo"})
+			})
+		})
+	})
+}
+
+func TestResultTags(t *testing.T) {
+	Convey("given a datadog collector for a circuit", t, func() {
+		collector := NewDatadogCollectorWithClient(nil)("foo").(*DatadogCollector)
+
+		Convey("context tags are added in key order", func() {
+			tags := collector.resultTags(metricCollector.MetricResult{Tags: map[string]string{"tenant": "a", "endpoint": "/users"}})
+			So(tags, ShouldResemble, []string{"hystrixcircuit:foo", "endpoint:/users", "tenant:a"})
+		})
+
+		Convey("rejections carry context tags before their bulkhead key", func() {
+			tags := collector.rejectTags(metricCollector.MetricResult{Tags: map[string]string{"tenant": "a"}, BulkheadKey: "a"})
+			So(tags, ShouldResemble, []string{"hystrixcircuit:foo", "tenant:a", "bulkheadkey:a"})
+		})
+	})
+}
+
+func TestFaultInjectedTags(t *testing.T) {
+	Convey("given a datadog collector for a circuit", t, func() {
+		collector := NewDatadogCollectorWithClient(nil)("foo").(*DatadogCollector)
+
+		Convey("executions with injected faults are tagged", func() {
+			tags := collector.resultTags(metricCollector.MetricResult{FaultInjected: 1})
+			So(tags, ShouldResemble, []string{"hystrixcircuit:foo", "faultinjected:true"})
+		})
+	})
+}
@@ -4,7 +4,6 @@ package plugins
 
 import (
 	"net"
-	"strings"
 	"time"
 
 	"github.com/afex/hystrix-go/hystrix/metric_collector"
@@ -19,7 +18,9 @@ var makeCounterFunc = func() interface{} { return metrics.NewCounter() }
 // circuits are started. Then register NewGraphiteCollector with metricCollector.Registry.Register(NewGraphiteCollector).
 //
 // This Collector uses github.com/rcrowley/go-metrics for aggregation. See that repo for more details
-// on how metrics are aggregated and expressed in graphite.
+// on how metrics are aggregated and expressed in graphite. MetricResult.Tags are ignored,
+// and rejections are attributed to their bulkhead key with a counter per key,
+// named {circuit_name}.bulkheadRejects.{key}.
 type GraphiteCollector struct {
 	attemptsPrefix          string
 	errorsPrefix            string
@@ -32,6 +33,20 @@ type GraphiteCollector struct {
 	fallbackFailuresPrefix  string
 	totalDurationPrefix     string
 	runDurationPrefix       string
+
+	rateLimitedPrefix          string
+	insufficientDeadlinePrefix string
+	slowStartRejectsPrefix     string
+	shedPrefix                 string
+	fallbackStalePrefix        string
+	faultInjectedPrefix        string
+	shadowShortCircuitsPrefix  string
+	shadowRejectsPrefix        string
+	shadowTimeoutsPrefix       string
+	droppedUpdatesPrefix       string
+	monitorLagPrefix           string
+
+	bulkheadRejectsPrefix string
 }
 
 // GraphiteCollectorConfig provides configuration that the graphite client will need.
@@ -54,9 +69,7 @@ func InitializeGraphiteCollector(config *GraphiteCollectorConfig) {
 // prefix given to this circuit will be {config.Prefix}.{circuit_name}.{metric}.
 // Circuits with "/" in their names will have them replaced with ".".
 func NewGraphiteCollector(name string) metricCollector.MetricCollector {
-	name = strings.Replace(name, "/", "-", -1)
-	name = strings.Replace(name, ":", "-", -1)
-	name = strings.Replace(name, ".", "-", -1)
+	name = metricName(name)
 	return &GraphiteCollector{
 		attemptsPrefix:          name + ".attempts",
 		errorsPrefix:            name + ".errors",
@@ -69,6 +82,20 @@ func NewGraphiteCollector(name string) metricCollector.MetricCollector {
 		fallbackFailuresPrefix:  name + ".fallbackFailures",
 		totalDurationPrefix:     name + ".totalDuration",
 		runDurationPrefix:       name + ".runDuration",
+
+		rateLimitedPrefix:          name + ".rateLimited",
+		insufficientDeadlinePrefix: name + ".insufficientDeadline",
+		slowStartRejectsPrefix:     name + ".slowStartRejects",
+		shedPrefix:                 name + ".shed",
+		fallbackStalePrefix:        name + ".fallbackStale",
+		faultInjectedPrefix:        name + ".faultInjected",
+		shadowShortCircuitsPrefix:  name + ".shadowShortCircuits",
+		shadowRejectsPrefix:        name + ".shadowRejects",
+		shadowTimeoutsPrefix:       name + ".shadowTimeouts",
+		droppedUpdatesPrefix:       name + ".droppedUpdates",
+		monitorLagPrefix:           name + ".monitorLag",
+
+		bulkheadRejectsPrefix: name + ".bulkheadRejects.",
 	}
 }
 
@@ -99,10 +126,28 @@ func (g *GraphiteCollector) Update(r metricCollector.MetricResult) {
 	g.incrementCounterMetric(g.rejectsPrefix, r.Rejects)
 	g.incrementCounterMetric(g.shortCircuitsPrefix, r.ShortCircuits)
 	g.incrementCounterMetric(g.timeoutsPrefix, r.Timeouts)
+	g.incrementCounterMetric(g.rateLimitedPrefix, r.RateLimited)
+	g.incrementCounterMetric(g.insufficientDeadlinePrefix, r.InsufficientDeadline)
+	g.incrementCounterMetric(g.slowStartRejectsPrefix, r.SlowStartRejects)
+	g.incrementCounterMetric(g.shedPrefix, r.Shed)
 	g.incrementCounterMetric(g.fallbackSuccessesPrefix, r.FallbackSuccesses)
 	g.incrementCounterMetric(g.fallbackFailuresPrefix, r.FallbackFailures)
-	g.updateTimerMetric(g.totalDurationPrefix, r.TotalDuration)
-	g.updateTimerMetric(g.runDurationPrefix, r.RunDuration)
+	g.incrementCounterMetric(g.fallbackStalePrefix, r.FallbackStale)
+	g.incrementCounterMetric(g.faultInjectedPrefix, r.FaultInjected)
+	g.incrementCounterMetric(g.shadowShortCircuitsPrefix, r.ShadowShortCircuits)
+	g.incrementCounterMetric(g.shadowRejectsPrefix, r.ShadowRejects)
+	g.incrementCounterMetric(g.shadowTimeoutsPrefix, r.ShadowTimeouts)
+	g.incrementCounterMetric(g.droppedUpdatesPrefix, r.DroppedUpdates)
+	if r.BulkheadKey != "" {
+		g.incrementCounterMetric(g.bulkheadRejectsPrefix+metricName(r.BulkheadKey), r.Rejects)
+	}
+	if !r.Aggregated {
+		g.updateTimerMetric(g.totalDurationPrefix, r.TotalDuration)
+		g.updateTimerMetric(g.monitorLagPrefix, r.MonitorLag)
+	}
+	if r.Ran() {
+		g.updateTimerMetric(g.runDurationPrefix, r.RunDuration)
+	}
 }
 
 // Reset is a noop operation in this collector.
@@ -0,0 +1,142 @@
+package plugins
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys set on the spans of an OpenTelemetryTracer.
+const (
+	OTelCircuitKey          = attribute.Key("hystrix.circuit")
+	OTelEventsKey           = attribute.Key("hystrix.events")
+	OTelShortCircuitedKey   = attribute.Key("hystrix.short_circuited")
+	OTelRejectedKey         = attribute.Key("hystrix.rejected")
+	OTelConcurrencyInUseKey = attribute.Key("hystrix.concurrency_in_use")
+)
+
+// OpenTelemetryTracer fulfills the hystrix.Tracer interface, recording each
+// command execution as a span with child spans for run and the fallback. Every
+// span carries the command's events, whether it was short-circuited or
+// rejected, and the share of the pool in use, so the child spans are only
+// exported once the command has finished.
+// Command spans are children of the span in the caller's context, and run is
+// called with a context carrying its own span, so that its work is traced
+// beneath it.
+//
+// Example use
+//
+//	hystrix.SetTracer(plugins.NewOpenTelemetryTracer(otel.Tracer("hystrix")))
+type OpenTelemetryTracer struct {
+	tracer trace.Tracer
+}
+
+// NewOpenTelemetryTracer creates a tracer which records spans with the given trace.Tracer.
+func NewOpenTelemetryTracer(tracer trace.Tracer) *OpenTelemetryTracer {
+	return &OpenTelemetryTracer{tracer: tracer}
+}
+
+// StartCommand opens the span for a command execution.
+func (t *OpenTelemetryTracer) StartCommand(ctx context.Context, name string) (context.Context, hystrix.CommandSpan) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(OTelCircuitKey.String(name)))
+	return ctx, &otelCommandSpan{tracer: t.tracer, name: name, span: span, mutex: &sync.Mutex{}}
+}
+
+type otelCommandSpan struct {
+	tracer trace.Tracer
+	name   string
+	span   trace.Span
+
+	mutex *sync.Mutex
+	// children are the run and fallback spans which are waiting for the
+	// command's outcome, so that they can carry it too.
+	children []*otelChildSpan
+	// attributes describe the outcome, once End has been called.
+	attributes []attribute.KeyValue
+}
+
+type otelChildSpan struct {
+	span     trace.Span
+	returned bool
+	endTime  time.Time
+}
+
+func (s *otelCommandSpan) StartRun(ctx context.Context) (context.Context, func(error)) {
+	return s.startChild(ctx, s.name+".run")
+}
+
+func (s *otelCommandSpan) StartFallback(ctx context.Context) (context.Context, func(error)) {
+	return s.startChild(ctx, s.name+".fallback")
+}
+
+// startChild opens a child span, which ends when the returned function is
+// called or, if the outcome isn't known yet, once End has described it.
+func (s *otelCommandSpan) startChild(ctx context.Context, spanName string) (context.Context, func(error)) {
+	ctx, span := s.tracer.Start(ctx, spanName, trace.WithAttributes(OTelCircuitKey.String(s.name)))
+	child := &otelChildSpan{span: span}
+
+	s.mutex.Lock()
+	s.children = append(s.children, child)
+	s.mutex.Unlock()
+
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if s.attributes == nil {
+			child.returned = true
+			child.endTime = time.Now()
+			return
+		}
+		// run was abandoned, and is only now returning.
+		span.SetAttributes(s.attributes...)
+		span.End()
+	}
+}
+
+func (s *otelCommandSpan) End(events []string, concurrencyInUse float64) {
+	var shortCircuited, rejected bool
+	if len(events) > 0 {
+		shortCircuited = events[0] == "short-circuit"
+		rejected = hystrix.IsRejection(events[0])
+	}
+
+	attributes := []attribute.KeyValue{
+		OTelEventsKey.StringSlice(events),
+		OTelShortCircuitedKey.Bool(shortCircuited),
+		OTelRejectedKey.Bool(rejected),
+		OTelConcurrencyInUseKey.Float64(concurrencyInUse),
+	}
+
+	s.mutex.Lock()
+	s.attributes = attributes
+	for _, child := range s.children {
+		if child.returned {
+			child.span.SetAttributes(attributes...)
+			child.span.End(trace.WithTimestamp(child.endTime))
+		}
+	}
+	s.children = nil
+	s.mutex.Unlock()
+
+	s.span.SetAttributes(attributes...)
+	if len(events) > 0 && !succeeded(events[len(events)-1]) {
+		s.span.SetStatus(codes.Error, events[len(events)-1])
+	}
+	s.span.End()
+}
+
+// succeeded reports whether an execution whose last event is the given one served a result.
+func succeeded(event string) bool {
+	return event == "success" || event == "fallback-success" || event == "fallback-stale"
+}
@@ -0,0 +1,135 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/afex/hystrix-go/hystrix/hystrixtest"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+// waitForSpans blocks until the exporter holds at least n spans, failing the
+// test if they are not exported within a second. Command spans end once the
+// execution's events are reported, which may be after DoC has returned.
+func waitForSpans(t *testing.T, exporter *tracetest.InMemoryExporter, n int) tracetest.SpanStubs {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		spans := exporter.GetSpans()
+		if len(spans) >= n {
+			return spans
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("waited for %v spans, got %v", n, len(spans))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestOpenTelemetryTracer(t *testing.T) {
+	Convey("with commands traced to an in-memory exporter", t, func() {
+		exporter := tracetest.NewInMemoryExporter()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		hystrixtest.Isolate(t)
+		hystrix.SetTracer(NewOpenTelemetryTracer(provider.Tracer("hystrix")))
+
+		ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+
+		Convey("a failed command with a fallback is traced beneath the caller's span", func() {
+			var runSpan trace.SpanContext
+			err := hystrix.DoC(ctx, "traced", func(ctx context.Context) error {
+				runSpan = trace.SpanContextFromContext(ctx)
+				return fmt.Errorf("run_error")
+			}, func(ctx context.Context, err error) error {
+				return nil
+			})
+			So(err, ShouldBeNil)
+			parent.End()
+
+			spans := map[string]tracetest.SpanStub{}
+			for _, span := range waitForSpans(t, exporter, 4) {
+				spans[span.Name] = span
+			}
+			So(len(spans), ShouldEqual, 4)
+
+			command := spans["traced"]
+			So(command.Parent.SpanID(), ShouldEqual, parent.SpanContext().SpanID())
+			So(spanAttribute(command, OTelCircuitKey).AsString(), ShouldEqual, "traced")
+			So(spanAttribute(command, OTelEventsKey).AsStringSlice(), ShouldResemble, []string{"failure", "fallback-success"})
+			So(spanAttribute(command, OTelShortCircuitedKey).AsBool(), ShouldBeFalse)
+			So(spanAttribute(command, OTelRejectedKey).AsBool(), ShouldBeFalse)
+			So(command.Status.Code, ShouldEqual, codes.Unset)
+
+			run := spans["traced.run"]
+			So(run.Parent.SpanID(), ShouldEqual, command.SpanContext.SpanID())
+			So(run.SpanContext.SpanID(), ShouldEqual, runSpan.SpanID())
+			So(run.Status.Code, ShouldEqual, codes.Error)
+			So(run.EndTime.After(command.EndTime), ShouldBeFalse)
+
+			fallback := spans["traced.fallback"]
+			So(fallback.Parent.SpanID(), ShouldEqual, command.SpanContext.SpanID())
+			So(fallback.Status.Code, ShouldEqual, codes.Unset)
+
+			Convey("and its child spans carry the outcome as well", func() {
+				for _, child := range []tracetest.SpanStub{run, fallback} {
+					So(spanAttribute(child, OTelCircuitKey).AsString(), ShouldEqual, "traced")
+					So(spanAttribute(child, OTelEventsKey).AsStringSlice(), ShouldResemble, []string{"failure", "fallback-success"})
+					So(spanAttribute(child, OTelShortCircuitedKey).AsBool(), ShouldBeFalse)
+					So(spanAttribute(child, OTelRejectedKey).AsBool(), ShouldBeFalse)
+					So(spanAttribute(child, OTelConcurrencyInUseKey).Type(), ShouldEqual, attribute.FLOAT64)
+				}
+			})
+		})
+
+		Convey("a short-circuited command is marked as such", func() {
+			hystrixtest.SetState(t, "open", hystrix.CircuitOpen)
+
+			err := hystrix.DoC(ctx, "open", func(ctx context.Context) error {
+				return nil
+			}, nil)
+			So(err, ShouldResemble, hystrix.ErrCircuitOpen)
+
+			spans := waitForSpans(t, exporter, 1)
+			So(len(spans), ShouldEqual, 1)
+			So(spanAttribute(spans[0], OTelShortCircuitedKey).AsBool(), ShouldBeTrue)
+			So(spanAttribute(spans[0], OTelRejectedKey).AsBool(), ShouldBeFalse)
+			So(spans[0].Status.Code, ShouldEqual, codes.Error)
+		})
+
+		Convey("a command turned away by admission control is marked as rejected", func() {
+			hystrix.ConfigureCommand("limited", hystrix.CommandConfig{RequestsPerSecond: 1, Burst: 1})
+			So(hystrix.DoC(ctx, "limited", func(ctx context.Context) error {
+				return nil
+			}, nil), ShouldBeNil)
+			waitForSpans(t, exporter, 2)
+			exporter.Reset()
+
+			err := hystrix.DoC(ctx, "limited", func(ctx context.Context) error {
+				return nil
+			}, nil)
+			So(err, ShouldResemble, hystrix.ErrRateLimited)
+
+			spans := waitForSpans(t, exporter, 1)
+			So(len(spans), ShouldEqual, 1)
+			So(spanAttribute(spans[0], OTelRejectedKey).AsBool(), ShouldBeTrue)
+		})
+	})
+}
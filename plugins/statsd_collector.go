@@ -14,6 +14,9 @@ import (
 // circuits are started. Then register NewStatsdCollector with metricCollector.Registry.Register(NewStatsdCollector).
 //
 // This Collector uses https://github.com/cactus/go-statsd-client/ for transport.
+// Statsd has no tags, so any MetricResult.Tags are ignored, and rejections are
+// attributed to their bulkhead key with a counter per key, named
+// {circuit_name}.bulkheadRejects.{key}.
 type StatsdCollector struct {
 	client                  statsd.Statter
 	circuitOpenPrefix       string
@@ -32,6 +35,20 @@ type StatsdCollector struct {
 	runDurationPrefix       string
 	concurrencyInUsePrefix  string
 	sampleRate              float32
+
+	rateLimitedPrefix          string
+	insufficientDeadlinePrefix string
+	slowStartRejectsPrefix     string
+	shedPrefix                 string
+	fallbackStalePrefix        string
+	faultInjectedPrefix        string
+	shadowShortCircuitsPrefix  string
+	shadowRejectsPrefix        string
+	shadowTimeoutsPrefix       string
+	droppedUpdatesPrefix       string
+	monitorLagPrefix           string
+
+	bulkheadRejectsPrefix string
 }
 
 type StatsdCollectorClient struct {
@@ -91,9 +108,7 @@ func (s *StatsdCollectorClient) NewStatsdCollector(name string) metricCollector.
 	if s.client == nil {
 		log.Fatalf("Statsd client must be initialized before circuits are created.")
 	}
-	name = strings.Replace(name, "/", "-", -1)
-	name = strings.Replace(name, ":", "-", -1)
-	name = strings.Replace(name, ".", "-", -1)
+	name = metricName(name)
 	return &StatsdCollector{
 		client:                  s.client,
 		circuitOpenPrefix:       name + ".circuitOpen",
@@ -112,9 +127,31 @@ func (s *StatsdCollectorClient) NewStatsdCollector(name string) metricCollector.
 		runDurationPrefix:       name + ".runDuration",
 		concurrencyInUsePrefix:  name + ".concurrencyInUse",
 		sampleRate:              s.sampleRate,
+
+		rateLimitedPrefix:          name + ".rateLimited",
+		insufficientDeadlinePrefix: name + ".insufficientDeadline",
+		slowStartRejectsPrefix:     name + ".slowStartRejects",
+		shedPrefix:                 name + ".shed",
+		fallbackStalePrefix:        name + ".fallbackStale",
+		faultInjectedPrefix:        name + ".faultInjected",
+		shadowShortCircuitsPrefix:  name + ".shadowShortCircuits",
+		shadowRejectsPrefix:        name + ".shadowRejects",
+		shadowTimeoutsPrefix:       name + ".shadowTimeouts",
+		droppedUpdatesPrefix:       name + ".droppedUpdates",
+		monitorLagPrefix:           name + ".monitorLag",
+
+		bulkheadRejectsPrefix: name + ".bulkheadRejects.",
 	}
 }
 
+// metricName replaces the characters statsd and graphite treat as separators.
+func metricName(name string) string {
+	name = strings.Replace(name, "/", "-", -1)
+	name = strings.Replace(name, ":", "-", -1)
+	name = strings.Replace(name, ".", "-", -1)
+	return name
+}
+
 func (g *StatsdCollector) setGauge(prefix string, value int64) {
 	err := g.client.Gauge(prefix, value, g.sampleRate)
 	if err != nil {
@@ -160,13 +197,31 @@ func (g *StatsdCollector) Update(r metricCollector.MetricResult) {
 	g.incrementCounterMetric(g.rejectsPrefix, r.Rejects)
 	g.incrementCounterMetric(g.shortCircuitsPrefix, r.ShortCircuits)
 	g.incrementCounterMetric(g.timeoutsPrefix, r.Timeouts)
+	g.incrementCounterMetric(g.rateLimitedPrefix, r.RateLimited)
+	g.incrementCounterMetric(g.insufficientDeadlinePrefix, r.InsufficientDeadline)
+	g.incrementCounterMetric(g.slowStartRejectsPrefix, r.SlowStartRejects)
+	g.incrementCounterMetric(g.shedPrefix, r.Shed)
 	g.incrementCounterMetric(g.fallbackSuccessesPrefix, r.FallbackSuccesses)
 	g.incrementCounterMetric(g.fallbackFailuresPrefix, r.FallbackFailures)
+	g.incrementCounterMetric(g.fallbackStalePrefix, r.FallbackStale)
+	g.incrementCounterMetric(g.faultInjectedPrefix, r.FaultInjected)
+	g.incrementCounterMetric(g.shadowShortCircuitsPrefix, r.ShadowShortCircuits)
+	g.incrementCounterMetric(g.shadowRejectsPrefix, r.ShadowRejects)
+	g.incrementCounterMetric(g.shadowTimeoutsPrefix, r.ShadowTimeouts)
 	g.incrementCounterMetric(g.canceledPrefix, r.ContextCanceled)
 	g.incrementCounterMetric(g.deadlinePrefix, r.ContextDeadlineExceeded)
-	g.updateTimerMetric(g.totalDurationPrefix, r.TotalDuration)
-	g.updateTimerMetric(g.runDurationPrefix, r.RunDuration)
-	g.updateTimingMetric(g.concurrencyInUsePrefix, int64(100*r.ConcurrencyInUse))
+	g.incrementCounterMetric(g.droppedUpdatesPrefix, r.DroppedUpdates)
+	if r.BulkheadKey != "" {
+		g.incrementCounterMetric(g.bulkheadRejectsPrefix+metricName(r.BulkheadKey), r.Rejects)
+	}
+	if !r.Aggregated {
+		g.updateTimerMetric(g.totalDurationPrefix, r.TotalDuration)
+		g.updateTimerMetric(g.monitorLagPrefix, r.MonitorLag)
+		g.updateTimingMetric(g.concurrencyInUsePrefix, int64(100*r.ConcurrencyInUse))
+	}
+	if r.Ran() {
+		g.updateTimerMetric(g.runDurationPrefix, r.RunDuration)
+	}
 }
 
 // Reset is a noop operation in this collector.
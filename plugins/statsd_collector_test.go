@@ -3,6 +3,9 @@ package plugins
 import (
 	"testing"
 
+	"github.com/afex/hystrix-go/hystrix/metric_collector"
+	"github.com/cactus/go-statsd-client/statsd"
+	"github.com/cactus/go-statsd-client/statsd/statsdtest"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -35,3 +38,24 @@ func TestSampleRate(t *testing.T) {
 		})
 	})
 }
+
+func TestBulkheadRejects(t *testing.T) {
+	Convey("with a collector sending to a recording client", t, func() {
+		sender := statsdtest.NewRecordingSender()
+		client, err := statsd.NewClientWithSender(sender, "test")
+		So(err, ShouldBeNil)
+		collector := (&StatsdCollectorClient{client: client, sampleRate: 1}).NewStatsdCollector("foo")
+
+		Convey("a rejection is counted for its bulkhead key", func() {
+			collector.Update(metricCollector.MetricResult{Rejects: 1, BulkheadKey: "tenant.a"})
+
+			So(sender.GetSent().CollectNamed("test.foo.bulkheadRejects.tenant-a").Values(), ShouldResemble, []string{"1"})
+		})
+
+		Convey("an execution which was not rejected counts nothing for its key", func() {
+			collector.Update(metricCollector.MetricResult{Successes: 1, BulkheadKey: "tenant.a"})
+
+			So(sender.GetSent().CollectNamed("test.foo.bulkheadRejects.tenant-a"), ShouldBeEmpty)
+		})
+	})
+}